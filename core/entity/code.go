@@ -1,5 +1,10 @@
 package entity
 
+import (
+	"path"
+	"strings"
+)
+
 type Map map[string]interface{}
 
 type Data struct {
@@ -7,11 +12,46 @@ type Data struct {
 }
 
 type SolrField struct {
-	ID      string `json:"id"`
-	FileID  string `json:"file_id"`
-	OwnerID string `json:"owner_id"`
-	Repo    string `json:"repo"`
-	Branch  string `json:"branch"`
+	ID          string   `json:"id"`
+	FileID      string   `json:"file_id"`
+	OwnerID     string   `json:"owner_id"`
+	Repo        string   `json:"repo"`
+	Branch      string   `json:"branch"`
+	Path        string   `json:"path"`
+	Lang        string   `json:"lang"`
+	ContentHash string   `json:"content_hash,omitempty"`
+	Content     []string `json:"content,omitempty"`
+}
+
+// FileName returns the last element of the file id, such as "main.go".
+func (f SolrField) FileName() string {
+	if f.FileID == "" {
+		return ""
+	}
+	return path.Base(f.FileID)
+}
+
+// Extension returns the extension of the file name without the dot, such
+// as "go", or "" when it has none. Dotfiles such as .gitignore have none.
+func (f SolrField) Extension() string {
+	name := strings.TrimPrefix(f.FileName(), ".")
+	return strings.TrimPrefix(path.Ext(name), ".")
+}
+
+// Host returns the git host the file was indexed from, which the indexer
+// puts first in the file id, such as "github.com" in
+// github.com/ahmadrosid/heline/main.go. It returns "" for file ids
+// without a host.
+func (f SolrField) Host() string {
+	i := strings.Index(f.FileID, "/")
+	if i < 0 {
+		return ""
+	}
+	host := f.FileID[:i]
+	if !strings.ContainsAny(host, ".:") {
+		return ""
+	}
+	return host
 }
 
 type SolrDoc struct {
@@ -35,12 +75,45 @@ type SolrFacet struct {
 	Repo struct {
 		Buckets SolrBuckets `json:"buckets"`
 	} `json:"repo"`
+	Branch struct {
+		Buckets SolrBuckets `json:"buckets"`
+	} `json:"branch"`
+	Owner struct {
+		Buckets SolrBuckets `json:"buckets"`
+	} `json:"owner_id"`
+}
+
+// SolrHeader is the responseHeader of a Solr response. PartialResults is
+// set when the search ran out of timeAllowed.
+type SolrHeader struct {
+	Status         int  `json:"status"`
+	PartialResults bool `json:"partialResults"`
+	QTime          int  `json:"QTime"`
+	// Params are the request params, echoed in debug responses.
+	Params Map `json:"params"`
 }
 
 type SolrResult struct {
-	Highlight map[string]Data `json:"highlighting"`
-	Response  SolrDoc         `json:"response"`
-	Facet     SolrFacet       `json:"facets"`
+	Header         SolrHeader      `json:"responseHeader"`
+	Highlight      map[string]Data `json:"highlighting"`
+	Response       SolrDoc         `json:"response"`
+	Facet          SolrFacet       `json:"facets"`
+	NextCursorMark string          `json:"nextCursorMark"`
+	// Expanded lists the other documents collapsed into each hit by
+	// content_hash when deduplicating.
+	Expanded map[string]SolrDoc `json:"expanded"`
+	// Debug is returned when debugQuery is on.
+	Debug *SolrDebug `json:"debug"`
+}
+
+// SolrDebug is the debug section of a Solr response.
+type SolrDebug struct {
+	QueryString         string            `json:"querystring"`
+	ParsedQuery         interface{}       `json:"parsedquery"`
+	FilterQueries       []string          `json:"filter_queries"`
+	ParsedFilterQueries []string          `json:"parsed_filter_queries"`
+	Explain             map[string]string `json:"explain"`
+	Timing              Map               `json:"timing"`
 }
 
 type ContentData struct {
@@ -50,6 +123,58 @@ type ContentData struct {
 	Branch  Map `json:"branch"`
 	Content Map `json:"content"`
 	Repo    Map `json:"repo"`
+	// Path is the directory of the file and Lang its language, as indexed.
+	Path      Map `json:"path"`
+	Lang      Map `json:"lang"`
+	FileName  Map `json:"file_name"`
+	Extension Map `json:"extension"`
+	Host      Map `json:"host"`
+	// Duplicates is the number of identical files collapsed into this hit.
+	// The first few are listed in OtherLocations.
+	Duplicates     int        `json:"duplicates,omitempty"`
+	OtherLocations []Location `json:"other_locations,omitempty"`
+}
+
+// Location is where a duplicate of a hit is indexed.
+type Location struct {
+	ID      string `json:"id"`
+	FileID  string `json:"file_id"`
+	Repo    string `json:"repo"`
+	Branch  string `json:"branch"`
+	OwnerID string `json:"owner_id"`
+}
+
+// SolrDocument is a single file as returned by the Solr real-time get
+// handler, with every stored content chunk.
+type SolrDocument struct {
+	ID      string   `json:"id"`
+	FileID  string   `json:"file_id"`
+	OwnerID string   `json:"owner_id"`
+	Repo    string   `json:"repo"`
+	Branch  string   `json:"branch"`
+	Path    string   `json:"path"`
+	Lang    string   `json:"lang"`
+	Content []string `json:"content"`
+}
+
+type SolrGetResult struct {
+	Doc      *SolrDocument `json:"doc"`
+	Response struct {
+		Docs []SolrDocument `json:"docs"`
+	} `json:"response"`
+}
+
+// CodeDocument is the full file view returned for tbm=docs requests.
+type CodeDocument struct {
+	ID      string `json:"id"`
+	FileID  string `json:"file_id"`
+	OwnerID string `json:"owner_id"`
+	Repo    string `json:"repo"`
+	Branch  string `json:"branch"`
+	Path    string `json:"path"`
+	Lang    string `json:"lang"`
+	Content string `json:"content"`
+	Chunks  int    `json:"chunks"`
 }
 
 type QueryParamFilter struct {
@@ -59,20 +184,89 @@ type QueryParamFilter struct {
 }
 
 type QueryParam struct {
-	Query string   `queryparam:"q"`
-	Tbm   string   `queryparam:"tbm"`
-	ID    string   `queryparam:"id"`
-	Path  []string `queryparam:"filter[path]"`
-	Lang  []string `queryparam:"filter[lang]"`
-	Repo  []string `queryparam:"filter[repo]"`
+	Query            string   `queryparam:"q"`
+	Tbm              string   `queryparam:"tbm"`
+	ID               string   `queryparam:"id"`
+	Path             []string `queryparam:"filter[path]"`
+	Lang             []string `queryparam:"filter[lang]"`
+	Repo             []string `queryparam:"filter[repo]"`
+	Branch           []string `queryparam:"filter[branch]"`
+	Owner            []string `queryparam:"filter[owner]"`
+	ExcludePath      []string `queryparam:"filter[-path]"`
+	ExcludeLang      []string `queryparam:"filter[-lang]"`
+	ExcludeRepo      []string `queryparam:"filter[-repo]"`
+	Page             int      `queryparam:"page"`
+	PerPage          int      `queryparam:"per_page"`
+	Cursor           string   `queryparam:"cursor"`
+	Mode             string   `queryparam:"mode"`
+	Case             string   `queryparam:"case"`
+	Word             string   `queryparam:"word"`
+	Export           bool     `queryparam:"export"`
+	Context          int      `queryparam:"context"`
+	Format           string   `queryparam:"format"`
+	Sort             string   `queryparam:"sort"`
+	Dedupe           bool     `queryparam:"dedupe"`
+	Debug            bool     `queryparam:"debug"`
+	LangFacetLimit   int      `queryparam:"facet_limit[lang]"`
+	PathFacetLimit   int      `queryparam:"facet_limit[path]"`
+	RepoFacetLimit   int      `queryparam:"facet_limit[repo]"`
+	BranchFacetLimit int      `queryparam:"facet_limit[branch]"`
+	OwnerFacetLimit  int      `queryparam:"facet_limit[owner]"`
 }
 
 type CodeHits struct {
-	Hits   []ContentData `json:"hits"`
-	Facets SolrFacet     `json:"facets"`
-	Total  int           `json:"total"`
+	Hits              []ContentData `json:"hits"`
+	Facets            SolrFacet     `json:"facets"`
+	Total             int           `json:"total"`
+	Page              int           `json:"page,omitempty"`
+	PerPage           int           `json:"per_page"`
+	NextCursor        string        `json:"next_cursor,omitempty"`
+	HasMore           bool          `json:"has_more"`
+	Suggestions       []string      `json:"suggestions,omitempty"`
+	SuggestionsFailed bool          `json:"suggestions_failed,omitempty"`
+	Partial           bool          `json:"partial,omitempty"`
 }
 
 type CodeSearchResult struct {
-	Response CodeHits `json:"hits"`
+	Response CodeHits     `json:"hits"`
+	Debug    *SearchDebug `json:"debug,omitempty"`
+}
+
+// SearchDebug explains how a search was run and scored, for debug=true.
+// Explain holds Solr's score explanation of each hit, keyed by id. Timing
+// holds Solr's per-component timing under "solr", its query time under
+// "qtime_ms" and the round trip to Solr, including reading the response,
+// under "request_ms".
+type SearchDebug struct {
+	Query          string            `json:"query"`
+	ParsedQuery    interface{}       `json:"parsed_query"`
+	HighlightQuery string            `json:"highlight_query"`
+	Filters        []string          `json:"filters"`
+	ParsedFilters  []string          `json:"parsed_filters"`
+	Explain        map[string]string `json:"explain"`
+	Timing         Map               `json:"timing"`
+}
+
+// SolrTermsResult is the terms handler response. Each field lists its
+// terms and counts flattened as [term, count, term, count, ...].
+type SolrTermsResult struct {
+	Terms map[string][]interface{} `json:"terms"`
+}
+
+type Suggestion struct {
+	Value string `json:"value"`
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+type SuggestResult struct {
+	Suggestions []Suggestion `json:"suggestions"`
+}
+
+// SolrSpellcheckResult is the spellcheck handler response. Collations are
+// flattened as ["collation", query, "collation", query, ...].
+type SolrSpellcheckResult struct {
+	Spellcheck struct {
+		Collations []interface{} `json:"collations"`
+	} `json:"spellcheck"`
 }
@@ -0,0 +1,16 @@
+package entity
+
+// ErrorResponse is the body of every error response of the API.
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// APIError describes why a request failed. Code is a stable identifier
+// for clients to switch on, Message is meant for people, and RequestID
+// matches the X-Request-ID header so failures can be traced in the logs.
+type APIError struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id"`
+}
@@ -0,0 +1,42 @@
+package entity
+
+import "time"
+
+// SearchFilters are the filter[...] params of a saved search.
+type SearchFilters struct {
+	Lang        []string `json:"lang,omitempty"`
+	Path        []string `json:"path,omitempty"`
+	Repo        []string `json:"repo,omitempty"`
+	Branch      []string `json:"branch,omitempty"`
+	Owner       []string `json:"owner,omitempty"`
+	ExcludeLang []string `json:"-lang,omitempty"`
+	ExcludePath []string `json:"-path,omitempty"`
+	ExcludeRepo []string `json:"-repo,omitempty"`
+}
+
+type SavedSearch struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	Query     string        `json:"query"`
+	Filters   SearchFilters `json:"filters"`
+	Sort      string        `json:"sort,omitempty"`
+	Owner     string        `json:"owner,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// QueryParam returns the search params that run the saved search.
+func (s SavedSearch) QueryParam() QueryParam {
+	return QueryParam{
+		Query:       s.Query,
+		Lang:        s.Filters.Lang,
+		Path:        s.Filters.Path,
+		Repo:        s.Filters.Repo,
+		Branch:      s.Filters.Branch,
+		Owner:       s.Filters.Owner,
+		ExcludeLang: s.Filters.ExcludeLang,
+		ExcludePath: s.Filters.ExcludePath,
+		ExcludeRepo: s.Filters.ExcludeRepo,
+		Sort:        s.Sort,
+	}
+}
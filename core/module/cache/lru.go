@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Stats reports how well the cache is doing.
+type Stats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"`
+	Bytes   int    `json:"bytes"`
+}
+
+// LRU is a byte-slice cache that evicts the least recently used entries
+// once it holds more than maxEntries entries or maxBytes bytes. Entries
+// also expire after ttl. It is safe for concurrent use.
+type LRU struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int
+	ttl        time.Duration
+	ll         *list.List
+	items      map[string]*list.Element
+	bytes      int
+	hits       uint64
+	misses     uint64
+	generation uint64
+	now        func() time.Time
+}
+
+type entry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// New returns an LRU with the given limits. A limit of 0 means no limit,
+// except that maxEntries 0 disables the cache altogether.
+func New(maxEntries, maxBytes int, ttl time.Duration) *LRU {
+	return &LRU{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ttl:        ttl,
+		ll:         list.New(),
+		items:      map[string]*list.Element{},
+		now:        time.Now,
+	}
+}
+
+// Get returns the value stored under key, unless it has expired. Callers
+// must not modify the returned slice.
+func (c *LRU) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if c.ttl > 0 && c.now().After(e.expires) {
+		c.remove(el)
+		c.misses++
+		return nil, false
+	}
+
+	c.ll.MoveToFront(el)
+	c.hits++
+	return e.value, true
+}
+
+// Add stores value under key, evicting older entries as needed. Values
+// larger than maxBytes are not stored.
+func (c *LRU) Add(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.add(key, value)
+}
+
+// Generation returns a number that changes every time the cache is purged.
+func (c *LRU) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// AddAt stores value under key like Add, unless the cache was purged since
+// Generation returned generation. Values computed before a purge are then
+// dropped instead of outliving it.
+func (c *LRU) AddAt(generation uint64, key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	c.add(key, value)
+}
+
+func (c *LRU) add(key string, value []byte) {
+	if c.maxEntries <= 0 || (c.maxBytes > 0 && len(value) > c.maxBytes) {
+		return
+	}
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+
+	el := c.ll.PushFront(&entry{
+		key:     key,
+		value:   value,
+		expires: c.now().Add(c.ttl),
+	})
+	c.items[key] = el
+	c.bytes += len(value)
+
+	for c.ll.Len() > c.maxEntries || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.remove(c.ll.Back())
+	}
+}
+
+// Purge drops every entry and starts a new generation. The hit and miss
+// counts are kept.
+func (c *LRU) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	c.items = map[string]*list.Element{}
+	c.bytes = 0
+	c.generation++
+}
+
+func (c *LRU) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Entries: c.ll.Len(),
+		Bytes:   c.bytes,
+	}
+}
+
+func (c *LRU) remove(el *list.Element) {
+	e := c.ll.Remove(el).(*entry)
+	delete(c.items, e.key)
+	c.bytes -= len(e.value)
+}
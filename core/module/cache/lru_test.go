@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// TestLRU tests eviction by entries, bytes and age, purge generations and
+// the stats
+func TestLRU(t *testing.T) {
+	now := time.Now()
+	c := New(2, 10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Add("a", []byte("1234"))
+	c.Add("b", []byte("1234"))
+	c.Get("a")
+	c.Add("c", []byte("12"))
+
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("Expected least recently used b to be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Errorf("Expected a to be kept")
+	}
+
+	c.Add("d", []byte("123456789"))
+	if stats := c.Stats(); stats.Entries != 1 || stats.Bytes != 9 {
+		t.Errorf("Expected only d to fit in 10 bytes, got %+v", stats)
+	}
+
+	c.Add("huge", []byte("12345678901"))
+	if _, ok := c.Get("huge"); ok {
+		t.Errorf("Expected a value over the byte limit not to be stored")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Get("d"); ok {
+		t.Errorf("Expected d to expire")
+	}
+
+	c.Add("e", []byte("1"))
+	c.Purge()
+	if _, ok := c.Get("e"); ok {
+		t.Errorf("Expected purge to drop e")
+	}
+
+	// A value read before the purge is not stored after it
+	generation := c.Generation()
+	c.Purge()
+	c.AddAt(generation, "f", []byte("1"))
+	if _, ok := c.Get("f"); ok {
+		t.Errorf("Expected a value from before the purge not to be stored")
+	}
+	c.AddAt(c.Generation(), "f", []byte("1"))
+	if _, ok := c.Get("f"); !ok {
+		t.Errorf("Expected a value from the current generation to be stored")
+	}
+	c.Purge()
+
+	stats := c.Stats()
+	if stats.Hits != 3 || stats.Misses != 5 || stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("Expected 3 hits, 5 misses and an empty cache, got %+v", stats)
+	}
+}
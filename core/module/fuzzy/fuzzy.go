@@ -0,0 +1,203 @@
+package fuzzy
+
+import "unicode"
+
+// Scores follow fzf: every matched character earns scoreMatch, gaps
+// between matches cost, and matches at the start of a word or right after
+// another match earn a bonus.
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+
+	bonusBoundary    = scoreMatch / 2
+	bonusNonWord     = scoreMatch / 2
+	bonusSeparator   = bonusBoundary + 1
+	bonusCamel       = bonusBoundary + scoreGapExtension
+	bonusConsecutive = -(scoreGapStart + scoreGapExtension)
+
+	// bonusFirstCharMultiplier weighs the bonus of the first pattern
+	// character, so "hs" prefers handle_search.go over phase.go.
+	bonusFirstCharMultiplier = 2
+)
+
+type charClass int
+
+const (
+	classNonWord charClass = iota
+	classSeparator
+	classLower
+	classUpper
+	classNumber
+)
+
+func classOf(c rune) charClass {
+	switch {
+	case c == '/':
+		return classSeparator
+	case unicode.IsUpper(c):
+		return classUpper
+	case unicode.IsLetter(c):
+		return classLower
+	case unicode.IsDigit(c):
+		return classNumber
+	default:
+		return classNonWord
+	}
+}
+
+// bonusFor returns the bonus of a match on a character of class cur that
+// follows one of class prev.
+func bonusFor(prev, cur charClass) int {
+	if cur >= classLower {
+		switch prev {
+		case classSeparator:
+			return bonusSeparator
+		case classNonWord:
+			return bonusBoundary
+		}
+	}
+	if prev == classLower && cur == classUpper || prev != classNumber && cur == classNumber {
+		return bonusCamel
+	}
+	if cur == classNonWord || cur == classSeparator {
+		return bonusNonWord
+	}
+	return 0
+}
+
+// Result is a fuzzy match of a pattern in a text.
+type Result struct {
+	Score int
+	// Positions are the rune offsets of the matched characters in the
+	// text, in ascending order.
+	Positions []int
+}
+
+// Match reports whether the characters of pattern appear in text in order
+// and scores the match. It uses smart case: the match ignores case unless
+// pattern has an upper case character. Every occurrence of the first
+// pattern character is tried as the start of a greedy match and the best
+// scoring one wins, so "main" prefers the file name in
+// ahmadrosid/heline/main.go over the letters spread across the repo.
+func Match(pattern, text string) (Result, bool) {
+	p := []rune(pattern)
+	t := []rune(text)
+	if len(p) == 0 || len(p) > len(t) {
+		return Result{}, false
+	}
+
+	caseSensitive := false
+	for _, c := range p {
+		if unicode.IsUpper(c) {
+			caseSensitive = true
+			break
+		}
+	}
+	fold := func(c rune) rune {
+		if caseSensitive {
+			return c
+		}
+		return unicode.ToLower(c)
+	}
+	for i := range p {
+		p[i] = fold(p[i])
+	}
+
+	var best Result
+	found := false
+	for start := range t {
+		if fold(t[start]) != p[0] {
+			continue
+		}
+
+		positions := greedyMatch(p, t, start, fold)
+		if positions == nil {
+			// Later starts cannot match either
+			break
+		}
+
+		score := calculateScore(t, positions)
+		if !found || score > best.Score {
+			best = Result{Score: score, Positions: positions}
+			found = true
+		}
+	}
+	return best, found
+}
+
+// greedyMatch matches each character of p at its first occurrence in t
+// from start onwards, returning nil when t runs out first.
+func greedyMatch(p, t []rune, start int, fold func(rune) rune) []int {
+	positions := make([]int, 0, len(p))
+	for i := start; i < len(t) && len(positions) < len(p); i++ {
+		if fold(t[i]) == p[len(positions)] {
+			positions = append(positions, i)
+		}
+	}
+	if len(positions) < len(p) {
+		return nil
+	}
+	return positions
+}
+
+// calculateScore scores the match of the characters at positions in t.
+func calculateScore(t []rune, positions []int) int {
+	score := 0
+	consecutive := 0
+	firstBonus := 0
+
+	prevClass := classSeparator
+	start := positions[0]
+	if start > 0 {
+		prevClass = classOf(t[start-1])
+	}
+
+	next := 0
+	for i := start; i <= positions[len(positions)-1]; i++ {
+		class := classOf(t[i])
+		if i != positions[next] {
+			if consecutive > 0 {
+				score += scoreGapStart
+			} else {
+				score += scoreGapExtension
+			}
+			consecutive = 0
+			firstBonus = 0
+			prevClass = class
+			continue
+		}
+
+		score += scoreMatch
+		bonus := bonusFor(prevClass, class)
+		if consecutive == 0 {
+			firstBonus = bonus
+		} else {
+			// A run keeps the bonus of its first character
+			if bonus >= bonusBoundary && bonus > firstBonus {
+				firstBonus = bonus
+			}
+			bonus = max(bonus, firstBonus, bonusConsecutive)
+		}
+		if next == 0 {
+			score += bonus * bonusFirstCharMultiplier
+		} else {
+			score += bonus
+		}
+
+		consecutive++
+		next++
+		prevClass = class
+	}
+	return score
+}
+
+func max(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,68 @@
+package fuzzy
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMatch tests which texts match and the matched positions
+func TestMatch(t *testing.T) {
+	testCases := []struct {
+		name      string
+		pattern   string
+		text      string
+		matched   bool
+		positions []int
+	}{
+		{"Subsequence", "hdl", "http/handler.go", true, []int{5, 8, 9}},
+		{"Out of order", "ldh", "http/handler.go", false, nil},
+		{"Ignores case", "readme", "docs/README.md", true, []int{5, 6, 7, 8, 9, 10}},
+		{"Smart case", "Readme", "docs/readme.md", false, nil},
+		{"Prefers the file name", "main", "ahmadrosid/heline/main.go", true, []int{18, 19, 20, 21}},
+		{"Unicode", "ñ", "src/España.rs", true, []int{8}},
+		{"Empty pattern", "", "main.go", false, nil},
+		{"Pattern longer than text", "main.go.rs", "main.go", false, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, ok := Match(tc.pattern, tc.text)
+			if ok != tc.matched {
+				t.Fatalf("Match(%q, %q) matched = %v, expected %v", tc.pattern, tc.text, ok, tc.matched)
+			}
+			if ok && !reflect.DeepEqual(result.Positions, tc.positions) {
+				t.Errorf("Expected positions %v, got %v", tc.positions, result.Positions)
+			}
+		})
+	}
+}
+
+// TestMatchRanking tests that better matches score higher
+func TestMatchRanking(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		better  string
+		worse   string
+	}{
+		{"handler", "http/handler.go", "http/hand_ler.go"},
+		{"hs", "http/handle_search.go", "core/phase.go"},
+		{"sq", "solr/SolrQuery.go", "solr/misquote.go"},
+		{"main", "cmd/main.go", "cmd/mandarin.go"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern, func(t *testing.T) {
+			better, ok := Match(tc.pattern, tc.better)
+			if !ok {
+				t.Fatalf("Expected %q to match %q", tc.pattern, tc.better)
+			}
+			worse, ok := Match(tc.pattern, tc.worse)
+			if !ok {
+				t.Fatalf("Expected %q to match %q", tc.pattern, tc.worse)
+			}
+			if better.Score <= worse.Score {
+				t.Errorf("Expected %q (%d) to score above %q (%d)", tc.better, better.Score, tc.worse, worse.Score)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package query
+
+import (
+	"strings"
+
+	"github.com/ahmadrosid/heline/core/module/solr/lucene"
+)
+
+const (
+	// ContentField is the Solr field content terms are matched against.
+	ContentField = "content"
+	// CaseSensitiveField is a copy of ContentField split into n-grams that
+	// keep letter case.
+	CaseSensitiveField = "content_cs"
+	// AllFields leaves terms without a field prefix so Solr searches them
+	// across every configured query field.
+	AllFields = ""
+)
+
+// clause builds the Lucene clause for t against field. Only positive bare
+// terms use field: phrases over n-grams are meaningless, and a negated term
+// on identifier_ngram would exclude every word containing it, so both
+// always target ContentField.
+func (t Term) clause(field string) lucene.Query {
+	if t.Phrase || t.Negated {
+		field = ContentField
+	}
+
+	var clause lucene.Query
+	if t.Phrase {
+		clause = lucene.Phrase(field, t.Value)
+	} else {
+		clause = lucene.Term(field, t.Value)
+	}
+
+	if t.Negated {
+		clause = lucene.Not(clause)
+	}
+	return clause
+}
+
+// Expression builds the Lucene query for the content terms against field.
+func (q *Query) Expression(field string) string {
+	return q.expression(field, true)
+}
+
+// Prefilter builds the Lucene query for the positive content terms against
+// field. Searches verified in the Go layer use it and check the negated
+// terms with Exclusions, since Solr cannot apply case or word boundaries.
+func (q *Query) Prefilter(field string) string {
+	return q.expression(field, false)
+}
+
+func (q *Query) expression(field string, withNegated bool) string {
+	var clauses []lucene.Query
+	positive := false
+
+	for _, group := range q.Groups {
+		if len(group) == 1 {
+			if group[0].Negated && !withNegated {
+				continue
+			}
+			clauses = append(clauses, group[0].clause(field))
+			positive = positive || !group[0].Negated
+			continue
+		}
+
+		var alternatives []lucene.Query
+		for _, term := range group {
+			alternatives = append(alternatives, term.clause(field))
+		}
+		clauses = append(clauses, lucene.Or(alternatives...))
+		positive = true
+	}
+
+	if len(clauses) == 0 {
+		return lucene.MatchAll().String()
+	}
+
+	// A purely negative query matches nothing unless it starts from all docs
+	if !positive {
+		clauses = append([]lucene.Query{lucene.MatchAll()}, clauses...)
+	}
+
+	return lucene.Build(lucene.And(clauses...))
+}
+
+// HighlightExpression builds the query used for highlighting, which only
+// includes the terms that can actually appear in a hit. Bare terms also
+// match inside longer tokens, so hits found through identifier_ngram,
+// which is not stored and cannot be highlighted, still get a snippet.
+func (q *Query) HighlightExpression() string {
+	var clauses []lucene.Query
+	for _, group := range q.Groups {
+		for _, term := range group {
+			if term.Negated {
+				continue
+			}
+			clauses = append(clauses, term.clause(ContentField))
+			if !term.Phrase {
+				clauses = append(clauses, lucene.Contains(ContentField, term.Value))
+			}
+		}
+	}
+	return lucene.Build(lucene.Or(clauses...))
+}
+
+// FieldFilterQueries turns the qualifiers on field into Solr filter
+// queries, one per polarity with the included values first. Repeated
+// qualifiers for the same field are ORed.
+func (q *Query) FieldFilterQueries(field string) []string {
+	var filter []string
+	for _, negated := range []bool{false, true} {
+		var values []string
+		for _, f := range q.Filters {
+			if f.Field == field && f.Negated == negated {
+				values = append(values, f.Value)
+			}
+		}
+
+		if len(values) == 0 {
+			continue
+		}
+
+		filter = append(filter, FilterQuery(field, values, negated))
+	}
+	return filter
+}
+
+// FilterQuery builds a filter query matching field against any of values,
+// or excluding them when negated, the way qualifiers match. Values are
+// escaped, repo values match anywhere in the stored value so repo:heline
+// finds ahmadrosid/heline, and path values match with PathSegment.
+func FilterQuery(field string, values []string, negated bool) string {
+	match := lucene.Term
+	switch field {
+	case "repo":
+		match = lucene.Contains
+	case "path":
+		match = PathSegment
+	}
+	return MatchFilterQuery(field, values, match, negated)
+}
+
+// PathSegment matches field values with a path segment starting with value.
+// Indexed paths start with the directories above the file, such as
+// heline/vendor, so vendor/ matches there as well as at the start.
+func PathSegment(field, value string) lucene.Query {
+	value = strings.TrimPrefix(value, "/")
+	return lucene.Or(lucene.Prefix(field, value), lucene.Contains(field, "/"+value))
+}
+
+// MatchFilterQuery builds a filter query matching field against any of
+// values with match, such as lucene.Term, or excluding them when negated.
+func MatchFilterQuery(field string, values []string, match func(field, value string) lucene.Query, negated bool) string {
+	clauses := make([]lucene.Query, len(values))
+	for i, value := range values {
+		clauses[i] = match(field, value)
+	}
+
+	fq := lucene.Or(clauses...)
+	if negated {
+		fq = lucene.Not(fq)
+	}
+	return lucene.Build(fq)
+}
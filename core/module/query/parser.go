@@ -0,0 +1,285 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Qualifiers are the field prefixes understood by the parser. Any other
+// "name:value" token is treated as code and searched as-is.
+var Qualifiers = []string{"repo", "lang", "path", "branch"}
+
+// Term is a single content search term or quoted phrase.
+type Term struct {
+	Value   string
+	Phrase  bool
+	Negated bool
+}
+
+// Filter is a qualifier such as repo:heline or -path:vendor.
+type Filter struct {
+	Field   string
+	Value   string
+	Negated bool
+}
+
+// Query is the parsed form of an inline search query. Groups are combined
+// with AND, the terms inside a group are alternatives joined with OR.
+type Query struct {
+	Groups  [][]Term
+	Filters []Filter
+}
+
+// ParseError reports invalid syntax. Pos is the 0-based byte offset in the
+// original input where the problem was detected.
+type ParseError struct {
+	Pos     int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Pos)
+}
+
+type parser struct {
+	input     string
+	pos       int
+	query     *Query
+	pendingOr int
+	lastKind  tokenKind
+}
+
+type tokenKind int
+
+const (
+	tokenNone tokenKind = iota
+	tokenTerm
+	tokenFilter
+	tokenOr
+)
+
+// Parse turns an inline query like
+//
+//	repo:foo lang:go -path:vendor "exact phrase" -term a OR b
+//
+// into content terms and qualifier filters.
+func Parse(input string) (*Query, error) {
+	p := &parser{
+		input:     input,
+		query:     &Query{},
+		pendingOr: -1,
+	}
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.input) {
+			break
+		}
+
+		if err := p.parseToken(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.lastKind == tokenOr {
+		return nil, &ParseError{Pos: p.pendingOr, Message: "OR must be followed by a search term"}
+	}
+
+	return p.query, nil
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.input) && isSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *parser) parseToken() error {
+	start := p.pos
+	negated := false
+
+	if p.input[p.pos] == '-' && p.pos+1 < len(p.input) && isNegatable(p.input[p.pos+1]) {
+		negated = true
+		p.pos++
+	}
+
+	if p.input[p.pos] == '"' {
+		value, err := p.readQuoted()
+		if err != nil {
+			return err
+		}
+		return p.addTerm(start, Term{Value: value, Phrase: true, Negated: negated})
+	}
+
+	var word strings.Builder
+	for p.pos < len(p.input) && !isSpace(p.input[p.pos]) {
+		c := p.input[p.pos]
+
+		if c == '\\' && p.pos+1 < len(p.input) {
+			word.WriteByte(p.input[p.pos+1])
+			p.pos += 2
+			continue
+		}
+
+		if c == ':' && isQualifier(word.String()) {
+			field := word.String()
+			p.pos++
+
+			var value string
+			if p.pos < len(p.input) && p.input[p.pos] == '"' {
+				v, err := p.readQuoted()
+				if err != nil {
+					return err
+				}
+				value = v
+			} else {
+				value = p.readWord()
+			}
+
+			if value == "" {
+				return &ParseError{Pos: start, Message: fmt.Sprintf("missing value for qualifier %s:", field)}
+			}
+
+			return p.addFilter(start, Filter{Field: field, Value: value, Negated: negated})
+		}
+
+		word.WriteByte(c)
+		p.pos++
+	}
+
+	if !negated && word.String() == "OR" && p.input[start:p.pos] == "OR" {
+		return p.addOr(start)
+	}
+
+	return p.addTerm(start, Term{Value: word.String(), Negated: negated})
+}
+
+// readQuoted consumes a double-quoted string starting at the current
+// position and returns its unescaped contents.
+func (p *parser) readQuoted() (string, error) {
+	open := p.pos
+	p.pos++
+
+	var value strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.input):
+			value.WriteByte(p.input[p.pos+1])
+			p.pos += 2
+		case c == '"':
+			p.pos++
+			if value.Len() == 0 {
+				return "", &ParseError{Pos: open, Message: "empty phrase"}
+			}
+			return value.String(), nil
+		default:
+			value.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	return "", &ParseError{Pos: open, Message: "unterminated quoted phrase"}
+}
+
+func (p *parser) readWord() string {
+	var word strings.Builder
+	for p.pos < len(p.input) && !isSpace(p.input[p.pos]) {
+		if p.input[p.pos] == '\\' && p.pos+1 < len(p.input) {
+			p.pos++
+		}
+		word.WriteByte(p.input[p.pos])
+		p.pos++
+	}
+	return word.String()
+}
+
+func (p *parser) addTerm(pos int, term Term) error {
+	if p.lastKind == tokenOr {
+		if term.Negated {
+			return &ParseError{Pos: pos, Message: "negated terms cannot be combined with OR"}
+		}
+
+		last := len(p.query.Groups) - 1
+		p.query.Groups[last] = append(p.query.Groups[last], term)
+	} else {
+		p.query.Groups = append(p.query.Groups, []Term{term})
+	}
+
+	p.lastKind = tokenTerm
+	return nil
+}
+
+func (p *parser) addFilter(pos int, filter Filter) error {
+	if p.lastKind == tokenOr {
+		return &ParseError{Pos: pos, Message: "qualifiers cannot be combined with OR"}
+	}
+
+	p.query.Filters = append(p.query.Filters, filter)
+	p.lastKind = tokenFilter
+	return nil
+}
+
+func (p *parser) addOr(pos int) error {
+	switch p.lastKind {
+	case tokenNone:
+		return &ParseError{Pos: pos, Message: "OR must follow a search term"}
+	case tokenOr:
+		return &ParseError{Pos: pos, Message: "unexpected OR"}
+	case tokenFilter:
+		return &ParseError{Pos: pos, Message: "qualifiers cannot be combined with OR"}
+	}
+
+	last := p.query.Groups[len(p.query.Groups)-1]
+	if last[len(last)-1].Negated {
+		return &ParseError{Pos: pos, Message: "negated terms cannot be combined with OR"}
+	}
+
+	p.pendingOr = pos
+	p.lastKind = tokenOr
+	return nil
+}
+
+// IsSimple reports whether the query is a plain run of terms without
+// phrases, negation or OR, so it can be searched as free text.
+func (q *Query) IsSimple() bool {
+	for _, group := range q.Groups {
+		if len(group) > 1 {
+			return false
+		}
+		if group[0].Phrase || group[0].Negated {
+			return false
+		}
+	}
+	return true
+}
+
+// Text returns the content terms joined by spaces.
+func (q *Query) Text() string {
+	var terms []string
+	for _, group := range q.Groups {
+		for _, term := range group {
+			terms = append(terms, term.Value)
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+func isQualifier(name string) bool {
+	for _, q := range Qualifiers {
+		if q == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// isNegatable reports whether a leading '-' before c is a negation rather
+// than part of code such as "->" or "--".
+func isNegatable(c byte) bool {
+	return c == '"' || c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
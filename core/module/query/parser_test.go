@@ -0,0 +1,152 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParse tests parsing of qualifiers, phrases, negation and OR
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name            string
+		input           string
+		expectedGroups  [][]Term
+		expectedFilters []Filter
+	}{
+		{
+			name:           "Plain terms",
+			input:          "handleSearch solr",
+			expectedGroups: [][]Term{{{Value: "handleSearch"}}, {{Value: "solr"}}},
+		},
+		{
+			name:  "Qualifiers and content",
+			input: `repo:foo lang:go path:internal/ -path:vendor "exact phrase" -term`,
+			expectedGroups: [][]Term{
+				{{Value: "exact phrase", Phrase: true}},
+				{{Value: "term", Negated: true}},
+			},
+			expectedFilters: []Filter{
+				{Field: "repo", Value: "foo"},
+				{Field: "lang", Value: "go"},
+				{Field: "path", Value: "internal/"},
+				{Field: "path", Value: "vendor", Negated: true},
+			},
+		},
+		{
+			name:           "OR groups",
+			input:          "foo OR bar baz",
+			expectedGroups: [][]Term{{{Value: "foo"}, {Value: "bar"}}, {{Value: "baz"}}},
+		},
+		{
+			name:            "Quoted qualifier value",
+			input:           `branch:"release 1.0" main`,
+			expectedGroups:  [][]Term{{{Value: "main"}}},
+			expectedFilters: []Filter{{Field: "branch", Value: "release 1.0"}},
+		},
+		{
+			name:           "Code is not mistaken for syntax",
+			input:          `: void std::vector a->b fmt.Println("`,
+			expectedGroups: [][]Term{{{Value: ":"}}, {{Value: "void"}}, {{Value: "std::vector"}}, {{Value: "a->b"}}, {{Value: `fmt.Println("`}}},
+		},
+		{
+			name:           "Escaped quote starts a term",
+			input:          `\"hello`,
+			expectedGroups: [][]Term{{{Value: `"hello`}}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := Parse(tc.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tc.input, err)
+			}
+
+			if !reflect.DeepEqual(q.Groups, tc.expectedGroups) {
+				t.Errorf("Expected groups %+v, got %+v", tc.expectedGroups, q.Groups)
+			}
+
+			if !reflect.DeepEqual(q.Filters, tc.expectedFilters) {
+				t.Errorf("Expected filters %+v, got %+v", tc.expectedFilters, q.Filters)
+			}
+		})
+	}
+}
+
+// TestParseErrors tests that invalid syntax is reported with its position
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		input       string
+		expectedPos int
+	}{
+		{`foo "unterminated`, 4},
+		{`OR foo`, 0},
+		{`foo OR`, 4},
+		{`foo OR OR bar`, 7},
+		{`foo repo:`, 4},
+		{`foo OR -bar`, 7},
+		{`lang:go OR foo`, 8},
+		{`foo ""`, 4},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := Parse(tc.input)
+			if err == nil {
+				t.Fatalf("Expected Parse(%q) to fail", tc.input)
+			}
+
+			parseErr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("Expected *ParseError, got %T", err)
+			}
+
+			if parseErr.Pos != tc.expectedPos {
+				t.Errorf("Expected error at position %d, got %d (%v)", tc.expectedPos, parseErr.Pos, parseErr)
+			}
+		})
+	}
+}
+
+// TestExpression tests the Lucene query built from parsed terms and filters
+func TestExpression(t *testing.T) {
+	q, err := Parse(`repo:heline -path:vendor "a(b)" foo OR bar -baz`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	expected := `content:"a(b)" AND (content:foo OR content:bar) AND -content:baz`
+	if got := q.Expression(ContentField); got != expected {
+		t.Errorf("Expected expression %q, got %q", expected, got)
+	}
+
+	expectedPrefilter := `content:"a(b)" AND (content:foo OR content:bar)`
+	if got := q.Prefilter(ContentField); got != expectedPrefilter {
+		t.Errorf("Expected prefilter %q, got %q", expectedPrefilter, got)
+	}
+
+	expectedHighlight := `content:"a(b)" OR content:foo OR content:*foo* OR content:bar OR content:*bar*`
+	if got := q.HighlightExpression(); got != expectedHighlight {
+		t.Errorf("Expected highlight expression %q, got %q", expectedHighlight, got)
+	}
+
+	expectedFilters := map[string][]string{
+		"repo": {"repo:*heline*"},
+		"path": {`-(path:vendor* OR path:*\/vendor*)`},
+	}
+	for _, field := range Qualifiers {
+		if got := q.FieldFilterQueries(field); !reflect.DeepEqual(got, expectedFilters[field]) {
+			t.Errorf("Expected %s filters %v, got %v", field, expectedFilters[field], got)
+		}
+	}
+
+	expectedAllFields := `content:"a(b)" AND (foo OR bar) AND -content:baz`
+	if got := q.Expression(AllFields); got != expectedAllFields {
+		t.Errorf("Expected expression %q, got %q", expectedAllFields, got)
+	}
+
+	negative, _ := Parse("-foo")
+	if got := negative.Expression(ContentField); got != "*:* AND -content:foo" {
+		t.Errorf("Expected negative-only expression to start from all docs, got %q", got)
+	}
+}
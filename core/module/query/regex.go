@@ -0,0 +1,163 @@
+package query
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+
+	"github.com/ahmadrosid/heline/core/module/solr/lucene"
+)
+
+// minLiteralLen is the shortest literal worth turning into an index clause.
+// Shorter fragments match too many tokens to narrow the candidates.
+const minLiteralLen = 3
+
+// RegexPrefilter builds a Lucene query over the content field that every
+// document matching expr is guaranteed to satisfy. It is built from the
+// literal substrings expr requires, so the result still has to be verified
+// with the real regexp. It returns "*:*" when no literal can be used.
+func RegexPrefilter(expr string) (string, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+
+	var clauses []lucene.Query
+	for _, literal := range requiredLiterals(re.Simplify()) {
+		clauses = append(clauses, literalClauses(literal)...)
+	}
+
+	if len(clauses) == 0 {
+		return lucene.MatchAll().String(), nil
+	}
+	return lucene.Build(lucene.And(clauses...)), nil
+}
+
+// requiredLiterals returns literal strings that appear in every match of re.
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min >= 1 {
+			return requiredLiterals(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		var literals []string
+		var current []rune
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral {
+				current = append(current, sub.Rune...)
+				continue
+			}
+			if len(current) > 0 {
+				literals = append(literals, string(current))
+				current = nil
+			}
+			literals = append(literals, requiredLiterals(sub)...)
+		}
+		if len(current) > 0 {
+			literals = append(literals, string(current))
+		}
+		return literals
+	}
+	return nil
+}
+
+// literalClauses turns a literal into term clauses matching how the
+// content analyzer tokenizes code. Words cut off at either end of the
+// literal may be part of a longer token, so they become wildcards.
+func literalClauses(literal string) []lucene.Query {
+	isWord := func(r rune) bool {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+
+	runes := []rune(literal)
+	var clauses []lucene.Query
+	for i := 0; i < len(runes); {
+		if !isWord(runes[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(runes) && isWord(runes[i]) {
+			i++
+		}
+		word := strings.ToLower(string(runes[start:i]))
+
+		atStart, atEnd := start == 0, i == len(runes)
+		if (atStart || atEnd) && len([]rune(word)) < minLiteralLen {
+			continue
+		}
+
+		switch {
+		case atStart && atEnd:
+			clauses = append(clauses, lucene.Contains(ContentField, word))
+		case atStart:
+			clauses = append(clauses, lucene.Suffix(ContentField, word))
+		case atEnd:
+			clauses = append(clauses, lucene.Prefix(ContentField, word))
+		default:
+			clauses = append(clauses, lucene.Term(ContentField, word))
+		}
+	}
+	return clauses
+}
+
+// SubsequenceQuery builds a Lucene regexp query matching the values of
+// field that contain the characters of pattern in order, with any text
+// between them. It ignores case unless pattern has an upper case
+// character, like fuzzy.Match, so it selects exactly the values that
+// fuzzy.Match accepts.
+func SubsequenceQuery(field, pattern string) string {
+	caseSensitive := strings.IndexFunc(pattern, unicode.IsUpper) >= 0
+
+	var b strings.Builder
+	b.WriteString(".*")
+	for _, c := range pattern {
+		lower, upper := unicode.ToLower(c), unicode.ToUpper(c)
+		switch {
+		case !caseSensitive && lower != upper:
+			fmt.Fprintf(&b, "[%c%c]", lower, upper)
+		case unicode.IsLetter(c) || unicode.IsDigit(c):
+			b.WriteRune(c)
+		default:
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		}
+		b.WriteString(".*")
+	}
+	return fmt.Sprintf("%s:/%s/", field, b.String())
+}
+
+// BigramQuery ORs the pairs of adjacent letters and digits of pattern as
+// terms of field, an n-gram field. It scores the values that fuzzy match
+// pattern the way fzf ranks them, higher when more of pattern appears in a
+// row and when the value is shorter. It returns "*:*" when pattern has no
+// such pair.
+func BigramQuery(field, pattern string) string {
+	var clauses []lucene.Query
+	seen := map[string]bool{}
+	runes := []rune(strings.ToLower(pattern))
+	for i := 0; i+1 < len(runes); i++ {
+		bigram := string(runes[i : i+2])
+		if seen[bigram] || !isAlnum(runes[i]) || !isAlnum(runes[i+1]) {
+			continue
+		}
+		seen[bigram] = true
+		clauses = append(clauses, lucene.Term(field, bigram))
+	}
+
+	if len(clauses) == 0 {
+		return lucene.MatchAll().String()
+	}
+	return lucene.Build(lucene.Or(clauses...))
+}
+
+func isAlnum(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c)
+}
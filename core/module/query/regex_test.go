@@ -0,0 +1,78 @@
+package query
+
+import "testing"
+
+// TestRegexPrefilter tests the index query derived from regex literals
+func TestRegexPrefilter(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		expected string
+	}{
+		{`handleSearch`, `content:*handlesearch*`},
+		{`func\s+handle\w+\(w http`, `content:*func* AND content:*handle* AND content:w AND content:http*`},
+		{`foo.bar(baz)+`, `content:*foo* AND content:*bar* AND content:*baz*`},
+		{`(foo|bar)\d*`, `*:*`},
+		{`ab`, `*:*`},
+		{`x := ab\.Run`, `content:ab AND content:run*`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern, func(t *testing.T) {
+			got, err := RegexPrefilter(tc.pattern)
+			if err != nil {
+				t.Fatalf("RegexPrefilter(%q) failed: %v", tc.pattern, err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+
+	if _, err := RegexPrefilter(`(unclosed`); err == nil {
+		t.Errorf("Expected an error for an invalid pattern")
+	}
+}
+
+// TestSubsequenceQuery tests the regexp query selecting fuzzy path matches
+func TestSubsequenceQuery(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		expected string
+	}{
+		{`hdl`, `id:/.*[hH].*[dD].*[lL].*/`},
+		{`Hd`, `id:/.*H.*d.*/`},
+		{`a/b.go`, `id:/.*[aA].*\/.*[bB].*\..*[gG].*[oO].*/`},
+		{`v2`, `id:/.*[vV].*2.*/`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern, func(t *testing.T) {
+			got := SubsequenceQuery("id", tc.pattern)
+			if got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestBigramQuery tests the query ranking fuzzy path matches
+func TestBigramQuery(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		expected string
+	}{
+		{`Hdl`, `path_ngram:hd OR path_ngram:dl`},
+		{`a/b.go`, `path_ngram:go`},
+		{`abab`, `path_ngram:ab OR path_ngram:ba`},
+		{`x`, `*:*`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern, func(t *testing.T) {
+			got := BigramQuery("path_ngram", tc.pattern)
+			if got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
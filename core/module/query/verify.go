@@ -0,0 +1,83 @@
+package query
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var spaceRun = regexp.MustCompile(`\s+`)
+
+// Patterns returns one regexp per positive term group, used to verify the
+// Solr candidates in the Go layer. A document matches the query when every
+// pattern matches its text. Terms are matched case-insensitively unless
+// caseSensitive is set; wholeWord stops them matching inside longer words.
+func (q *Query) Patterns(caseSensitive, wholeWord bool) []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	for _, group := range q.Groups {
+		var alternatives []string
+		for _, term := range group {
+			if !term.Negated {
+				alternatives = append(alternatives, termPattern(term, wholeWord))
+			}
+		}
+
+		if len(alternatives) == 0 {
+			continue
+		}
+		patterns = append(patterns, compilePattern(alternatives, caseSensitive))
+	}
+	return patterns
+}
+
+// Exclusions returns one regexp per negated term, matched like Patterns.
+// A document is excluded when any of them matches its text.
+func (q *Query) Exclusions(caseSensitive, wholeWord bool) []*regexp.Regexp {
+	var exclusions []*regexp.Regexp
+	for _, group := range q.Groups {
+		for _, term := range group {
+			if term.Negated {
+				exclusions = append(exclusions, compilePattern([]string{termPattern(term, wholeWord)}, caseSensitive))
+			}
+		}
+	}
+	return exclusions
+}
+
+func compilePattern(alternatives []string, caseSensitive bool) *regexp.Regexp {
+	expr := strings.Join(alternatives, "|")
+	if !caseSensitive {
+		expr = "(?i)" + expr
+	}
+	return regexp.MustCompile(expr)
+}
+
+func termPattern(term Term, wholeWord bool) string {
+	expr := regexp.QuoteMeta(term.Value)
+	if term.Phrase {
+		// Phrases match regardless of how the words are spaced
+		expr = spaceRun.ReplaceAllString(expr, `\s+`)
+	}
+
+	if wholeWord {
+		// \b only makes sense next to word characters, so code like "foo("
+		// is bounded on the left only.
+		first, _ := utf8.DecodeRuneInString(term.Value)
+		last, _ := utf8.DecodeLastRuneInString(term.Value)
+		if isWordRune(first) {
+			expr = `\b` + expr
+		}
+		if isWordRune(last) {
+			expr = expr + `\b`
+		}
+	}
+
+	return "(?:" + expr + ")"
+}
+
+// isWordRune matches the ASCII-only definition of a word character used by
+// \b in Go regexps.
+func isWordRune(r rune) bool {
+	return r < utf8.RuneSelf && (r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r))
+}
@@ -0,0 +1,57 @@
+package query
+
+import "testing"
+
+// TestPatterns tests case-sensitive and whole-word verification patterns
+// and exclusions
+func TestPatterns(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         string
+		caseSensitive bool
+		wholeWord     bool
+		text          string
+		expectMatch   bool
+	}{
+		{"Case-insensitive by default", "Context", false, false, "ctx context.Context", true},
+		{"Case-sensitive rejects other case", "Context", true, false, "ctx context", false},
+		{"Case-sensitive accepts exact case", "Context", true, false, "ctx context.Context", true},
+		{"Case-sensitive matches inside identifiers", "Context", true, false, "ctx := NewContext()", true},
+		{"Whole word rejects substring", "id", false, true, "valid := true", false},
+		{"Whole word accepts word", "id", false, true, "user.id = 1", true},
+		{"Whole word with trailing punctuation", "foo(", false, true, "barfoo(x) foo(y)", true},
+		{"Whole word with only a substring match", "foo(", false, true, "barfoo(x)", false},
+		{"Every group must match", "alpha beta", false, false, "alpha gamma", false},
+		{"OR group needs one alternative", "alpha OR beta gamma", false, false, "beta gamma", true},
+		{"Phrase tolerates spacing", `"func  main"`, false, false, "func main()", true},
+		{"Whole word negation keeps longer words", "bar -foo", false, true, "bar := foobar", true},
+		{"Whole word negation excludes the word", "bar -foo", false, true, "bar := foo()", false},
+		{"Case-sensitive negation ignores other case", "bar -Foo", true, false, "bar := foo", true},
+		{"Case-insensitive negation", "bar -Foo", false, false, "bar := foo", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := Parse(tc.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tc.input, err)
+			}
+
+			matched := true
+			for _, re := range q.Patterns(tc.caseSensitive, tc.wholeWord) {
+				if !re.MatchString(tc.text) {
+					matched = false
+				}
+			}
+			for _, re := range q.Exclusions(tc.caseSensitive, tc.wholeWord) {
+				if re.MatchString(tc.text) {
+					matched = false
+				}
+			}
+
+			if matched != tc.expectMatch {
+				t.Errorf("Expected match=%v for %q in %q", tc.expectMatch, tc.input, tc.text)
+			}
+		})
+	}
+}
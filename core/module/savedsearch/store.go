@@ -0,0 +1,185 @@
+package savedsearch
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ahmadrosid/heline/core/entity"
+)
+
+// ErrNotFound is returned for ids that are not in the store.
+var ErrNotFound = errors.New("saved search not found")
+
+// DefaultPath is where saved searches are kept unless SAVED_SEARCHES_PATH
+// is set.
+const DefaultPath = "data/saved_searches.json"
+
+// Store keeps saved searches in a single JSON file. Every change rewrites
+// the file, which is fine for the handful of searches a team keeps.
+type Store struct {
+	path     string
+	mu       sync.Mutex
+	searches map[string]entity.SavedSearch
+}
+
+// Open loads the store at path, starting empty if the file does not exist.
+func Open(path string) (*Store, error) {
+	store := &Store{
+		path:     path,
+		searches: map[string]entity.SavedSearch{},
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var searches []entity.SavedSearch
+	if err := json.Unmarshal(data, &searches); err != nil {
+		return nil, err
+	}
+	for _, search := range searches {
+		store.searches[search.ID] = search
+	}
+	return store, nil
+}
+
+// List returns the saved searches of owner, or all of them when owner is
+// empty, oldest first.
+func (s *Store) List(owner string) []entity.SavedSearch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	searches := []entity.SavedSearch{}
+	for _, search := range s.searches {
+		if owner == "" || search.Owner == owner {
+			searches = append(searches, search)
+		}
+	}
+	sortSearches(searches)
+	return searches
+}
+
+func (s *Store) Get(id string) (entity.SavedSearch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	search, ok := s.searches[id]
+	if !ok {
+		return entity.SavedSearch{}, ErrNotFound
+	}
+	return search, nil
+}
+
+// Create stores search under a new id.
+func (s *Store) Create(search entity.SavedSearch) (entity.SavedSearch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := newID()
+	if err != nil {
+		return entity.SavedSearch{}, err
+	}
+
+	search.ID = id
+	search.CreatedAt = time.Now().UTC()
+	search.UpdatedAt = search.CreatedAt
+	s.searches[id] = search
+
+	if err := s.save(); err != nil {
+		delete(s.searches, id)
+		return entity.SavedSearch{}, err
+	}
+	return search, nil
+}
+
+// Update replaces the saved search with id, keeping its creation time.
+func (s *Store) Update(id string, search entity.SavedSearch) (entity.SavedSearch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.searches[id]
+	if !ok {
+		return entity.SavedSearch{}, ErrNotFound
+	}
+
+	search.ID = id
+	search.CreatedAt = previous.CreatedAt
+	search.UpdatedAt = time.Now().UTC()
+	s.searches[id] = search
+
+	if err := s.save(); err != nil {
+		s.searches[id] = previous
+		return entity.SavedSearch{}, err
+	}
+	return search, nil
+}
+
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.searches[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	delete(s.searches, id)
+	if err := s.save(); err != nil {
+		s.searches[id] = previous
+		return err
+	}
+	return nil
+}
+
+// save writes all searches to a temporary file and renames it over the
+// store, so a crash never leaves a half-written file behind.
+func (s *Store) save() error {
+	searches := make([]entity.SavedSearch, 0, len(s.searches))
+	for _, search := range s.searches {
+		searches = append(searches, search)
+	}
+	sortSearches(searches)
+
+	data, err := json.MarshalIndent(searches, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func sortSearches(searches []entity.SavedSearch) {
+	sort.Slice(searches, func(i, j int) bool {
+		if !searches[i].CreatedAt.Equal(searches[j].CreatedAt) {
+			return searches[i].CreatedAt.Before(searches[j].CreatedAt)
+		}
+		return searches[i].ID < searches[j].ID
+	})
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
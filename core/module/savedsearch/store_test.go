@@ -0,0 +1,58 @@
+package savedsearch
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ahmadrosid/heline/core/entity"
+)
+
+// TestStore tests that saved searches survive reopening the store
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "searches", "saved.json")
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+
+	audit, err := store.Create(entity.SavedSearch{Name: "audit", Query: "os.Exec", Owner: "sec"})
+	if err != nil {
+		t.Fatalf("Failed to create search: %v", err)
+	}
+	todo, err := store.Create(entity.SavedSearch{Name: "todo", Query: "TODO", Owner: "infra"})
+	if err != nil {
+		t.Fatalf("Failed to create search: %v", err)
+	}
+
+	audit.Filters.Lang = []string{"go"}
+	if _, err := store.Update(audit.ID, audit); err != nil {
+		t.Fatalf("Failed to update search: %v", err)
+	}
+	if err := store.Delete(todo.ID); err != nil {
+		t.Fatalf("Failed to delete search: %v", err)
+	}
+	if err := store.Delete(todo.ID); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+
+	searches := reopened.List("")
+	if len(searches) != 1 {
+		t.Fatalf("Expected 1 search after reopening, got %d", len(searches))
+	}
+	if searches[0].ID != audit.ID || len(searches[0].Filters.Lang) != 1 {
+		t.Errorf("Expected updated audit search, got %+v", searches[0])
+	}
+	if !searches[0].CreatedAt.Equal(audit.CreatedAt) {
+		t.Errorf("Expected update to keep creation time %v, got %v", audit.CreatedAt, searches[0].CreatedAt)
+	}
+
+	if got := reopened.List("infra"); len(got) != 0 {
+		t.Errorf("Expected no searches for infra, got %d", len(got))
+	}
+}
@@ -0,0 +1,84 @@
+package snippet
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Row is the raw markup of one highlight-table row.
+type Row struct {
+	Number int
+	HTML   string
+}
+
+// SplitRows splits markup into its <tr> rows, keeping the syntax
+// highlighting markup of each row intact.
+func SplitRows(markup string) []Row {
+	var rows []Row
+	for {
+		start := strings.Index(markup, "<tr")
+		if start < 0 {
+			return rows
+		}
+		end := strings.Index(markup[start:], "</tr>")
+		if end < 0 {
+			return rows
+		}
+		end += start + len("</tr>")
+
+		row := Row{Number: len(rows) + 1, HTML: markup[start:end]}
+		if i := strings.Index(row.HTML, "data-line="); i >= 0 {
+			if n, ok := attr(row.HTML[i:], "data-line"); ok {
+				if number, err := strconv.Atoi(n); err == nil {
+					row.Number = number
+				}
+			}
+		}
+
+		rows = append(rows, row)
+		markup = markup[end:]
+	}
+}
+
+// WithContext rebuilds snippets so each matched line has context lines
+// before and after it, taken from the full document markup. Windows that
+// overlap or touch are merged, like grep -C. Matched rows keep the <mark>
+// tags from the highlighted snippets.
+func WithContext(snippets []string, document string, context int) []string {
+	rows := SplitRows(document)
+	if len(rows) == 0 {
+		return snippets
+	}
+
+	index := map[int]int{}
+	for i, row := range rows {
+		index[row.Number] = i
+	}
+
+	var matched []int
+	for _, markup := range snippets {
+		for _, row := range SplitRows(markup) {
+			i, ok := index[row.Number]
+			if !ok || !strings.Contains(row.HTML, "<mark>") {
+				continue
+			}
+			rows[i].HTML = row.HTML
+			matched = append(matched, i)
+		}
+	}
+
+	if len(matched) == 0 {
+		return snippets
+	}
+
+	var result []string
+	for _, window := range Windows(matched, context, len(rows)) {
+		var b strings.Builder
+		for _, row := range rows[window[0] : window[1]+1] {
+			b.WriteString(row.HTML)
+			b.WriteByte('\n')
+		}
+		result = append(result, b.String())
+	}
+	return result
+}
@@ -0,0 +1,199 @@
+package snippet
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Range is a half-open [Start, End) span of characters within one line.
+// Offsets count runes, not bytes, so they index []rune(line).
+type Range struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Match is the position of a match on a single line, with 1-based line
+// numbers and 0-based character columns.
+type Match struct {
+	Line  int `json:"line"`
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// LineRanges maps byte offsets into PlainText(lines), as returned by
+// regexp FindAllStringIndex, to per-line character ranges keyed by line
+// number. Matches spanning several lines are split at line breaks.
+func LineRanges(lines []Line, offsets [][]int) map[int][]Range {
+	ranges := map[int][]Range{}
+	offsets = append([][]int(nil), offsets...)
+
+	lineStart := 0
+	i := 0
+	for _, line := range lines {
+		lineEnd := lineStart + len(line.Text)
+
+		for ; i < len(offsets) && offsets[i][0] <= lineEnd; i++ {
+			start, end := offsets[i][0], offsets[i][1]
+			if start == end {
+				continue
+			}
+
+			if end > lineEnd {
+				// Keep the remainder for the following lines
+				if start < lineEnd {
+					ranges[line.Number] = append(ranges[line.Number], Range{
+						Start: utf8.RuneCountInString(line.Text[:start-lineStart]),
+						End:   utf8.RuneCountInString(line.Text),
+					})
+				}
+				offsets[i] = []int{lineEnd + 1, end}
+				break
+			}
+
+			ranges[line.Number] = append(ranges[line.Number], Range{
+				Start: utf8.RuneCountInString(line.Text[:start-lineStart]),
+				End:   utf8.RuneCountInString(line.Text[:end-lineStart]),
+			})
+		}
+
+		lineStart = lineEnd + 1
+	}
+
+	return ranges
+}
+
+// Matches flattens per-line ranges into a list ordered by position.
+func Matches(ranges map[int][]Range) []Match {
+	var matches []Match
+	for number, lineRanges := range ranges {
+		for _, r := range lineRanges {
+			matches = append(matches, Match{Line: number, Start: r.Start, End: r.End})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Line != matches[j].Line {
+			return matches[i].Line < matches[j].Line
+		}
+		return matches[i].Start < matches[j].Start
+	})
+	return matches
+}
+
+// Windows returns [first, last] line indexes covering every matched index
+// plus context lines on each side. Overlapping or adjacent windows are
+// merged, like grep -C.
+func Windows(matched []int, context int, total int) [][2]int {
+	sorted := append([]int(nil), matched...)
+	sort.Ints(sorted)
+
+	var windows [][2]int
+	for _, index := range sorted {
+		first := index - context
+		if first < 0 {
+			first = 0
+		}
+		last := index + context
+		if last > total-1 {
+			last = total - 1
+		}
+
+		if n := len(windows); n > 0 && first <= windows[n-1][1]+1 {
+			if last > windows[n-1][1] {
+				windows[n-1][1] = last
+			}
+			continue
+		}
+		windows = append(windows, [2]int{first, last})
+	}
+	return windows
+}
+
+// MergeRanges sorts ranges and merges the ones that overlap, as required by
+// RenderRows.
+func MergeRanges(ranges []Range) []Range {
+	sorted := append([]Range(nil), ranges...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+
+	var merged []Range
+	for _, r := range sorted {
+		if n := len(merged); n > 0 && r.Start <= merged[n-1].End {
+			if r.End > merged[n-1].End {
+				merged[n-1].End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// Position locates a highlighted snippet within its file.
+type Position struct {
+	StartLine int     `json:"start_line"`
+	EndLine   int     `json:"end_line"`
+	Matches   []Match `json:"matches"`
+}
+
+// Locate returns the line span of a highlight-table snippet and the
+// position of every <mark> in it.
+func Locate(markup string) Position {
+	var position Position
+	matches := []Match{}
+
+	for _, line := range ParseRows(markup) {
+		if position.StartLine == 0 || line.Number < position.StartLine {
+			position.StartLine = line.Number
+		}
+		if line.Number > position.EndLine {
+			position.EndLine = line.Number
+		}
+
+		for _, mark := range line.Marks {
+			matches = append(matches, Match{Line: line.Number, Start: mark.Start, End: mark.End})
+		}
+	}
+
+	position.Matches = matches
+	return position
+}
+
+// Text is a snippet converted to plain text for clients without an HTML
+// parser. Matches are character offsets into Text.
+type Text struct {
+	Text      string  `json:"text"`
+	StartLine int     `json:"start_line"`
+	EndLine   int     `json:"end_line"`
+	Matches   []Range `json:"matches"`
+}
+
+// ToText converts a highlight-table snippet to plain text lines joined by
+// newlines, keeping the offset of every <mark>.
+func ToText(markup string) Text {
+	result := Text{Matches: []Range{}}
+	var texts []string
+	offset := 0
+
+	for _, line := range ParseRows(markup) {
+		if result.StartLine == 0 {
+			result.StartLine = line.Number
+		}
+		result.EndLine = line.Number
+
+		for _, mark := range line.Marks {
+			result.Matches = append(result.Matches, Range{
+				Start: offset + mark.Start,
+				End:   offset + mark.End,
+			})
+		}
+
+		texts = append(texts, line.Text)
+		offset += utf8.RuneCountInString(line.Text) + 1
+	}
+
+	result.Text = strings.Join(texts, "\n")
+	return result
+}
@@ -0,0 +1,179 @@
+package snippet
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Line is one source line recovered from the indexer's highlight-table
+// markup, where each line is rendered as
+//
+//	<tr><td class="hl-num" data-line="12"></td><td>...code spans...</td></tr>
+//
+// Marks holds the character ranges wrapped in <mark> by the highlighter.
+type Line struct {
+	Number int     `json:"line"`
+	Text   string  `json:"text"`
+	Marks  []Range `json:"marks,omitempty"`
+}
+
+// ParseRows extracts the line number, plain text and marked ranges of
+// every table row in markup. Syntax highlighting spans are dropped and
+// entities are decoded. A mark that spans several rows is split per line.
+func ParseRows(markup string) []Line {
+	var lines []Line
+	var current *Line
+	var text strings.Builder
+	runes := 0
+	markDepth := 0
+	markStart := 0
+
+	closeMark := func() {
+		if current != nil && runes > markStart {
+			current.Marks = append(current.Marks, Range{Start: markStart, End: runes})
+		}
+	}
+
+	flush := func() {
+		if current != nil {
+			if markDepth > 0 {
+				closeMark()
+			}
+			current.Text = text.String()
+			lines = append(lines, *current)
+			current = nil
+		}
+		text.Reset()
+		runes = 0
+		markStart = 0
+	}
+
+	for i := 0; i < len(markup); {
+		if markup[i] != '<' {
+			next := strings.IndexByte(markup[i:], '<')
+			if next < 0 {
+				next = len(markup) - i
+			}
+			if current != nil {
+				segment := html.UnescapeString(markup[i : i+next])
+				text.WriteString(segment)
+				runes += utf8.RuneCountInString(segment)
+			}
+			i += next
+			continue
+		}
+
+		end := strings.IndexByte(markup[i:], '>')
+		if end < 0 {
+			break
+		}
+		tag := markup[i+1 : i+end]
+		i += end + 1
+
+		switch tagName(tag) {
+		case "tr":
+			flush()
+			current = &Line{Number: len(lines) + 1}
+		case "/tr":
+			flush()
+		case "td":
+			if n, ok := attr(tag, "data-line"); ok && current != nil {
+				if number, err := strconv.Atoi(n); err == nil {
+					current.Number = number
+				}
+			}
+		case "mark":
+			// Nested marks, as added by enhanceHighlighting, count once
+			if markDepth == 0 {
+				markStart = runes
+			}
+			markDepth++
+		case "/mark":
+			if markDepth == 0 {
+				continue
+			}
+			markDepth--
+			if markDepth == 0 {
+				closeMark()
+			}
+		}
+	}
+	flush()
+
+	return lines
+}
+
+// PlainText joins lines into the original file text.
+func PlainText(lines []Line) string {
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		texts[i] = line.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// RenderRows renders lines back into highlight-table rows, without syntax
+// colouring, wrapping the given character ranges of each line in <mark>.
+func RenderRows(lines []Line, marks map[int][]Range) string {
+	var b strings.Builder
+	for _, line := range lines {
+		fmt.Fprintf(&b, `<tr><td class="hl-num" data-line="%d"></td><td>`, line.Number)
+		b.WriteString(markText(line.Text, marks[line.Number]))
+		b.WriteString("</td></tr>\n")
+	}
+	return b.String()
+}
+
+func markText(text string, ranges []Range) string {
+	runes := []rune(text)
+	var b strings.Builder
+	pos := 0
+	for _, r := range ranges {
+		if r.Start < pos || r.End > len(runes) || r.Start >= r.End {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:r.Start])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[r.Start:r.End])))
+		b.WriteString("</mark>")
+		pos = r.End
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:])))
+	return b.String()
+}
+
+func tagName(tag string) string {
+	name := tag
+	if i := strings.IndexAny(tag, " \t\n/"); i > 0 {
+		name = tag[:i]
+	}
+	return strings.ToLower(name)
+}
+
+func attr(tag, name string) (string, bool) {
+	i := strings.Index(tag, name+"=")
+	if i < 0 {
+		return "", false
+	}
+	value := tag[i+len(name)+1:]
+	if len(value) == 0 {
+		return "", false
+	}
+
+	quote := value[0]
+	if quote != '"' && quote != '\'' {
+		if end := strings.IndexAny(value, " \t\n"); end >= 0 {
+			return value[:end], true
+		}
+		return value, true
+	}
+
+	end := strings.IndexByte(value[1:], quote)
+	if end < 0 {
+		return "", false
+	}
+	return value[1 : end+1], true
+}
@@ -0,0 +1,167 @@
+package snippet
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+const sampleRows = `<tr><td class="hl-num" data-line="7"></td><td><span class="hl-k">func</span> <span class="hl-en">main</span>() {</td></tr>
+<tr><td class="hl-num" data-line="8"></td><td>	fmt.Println(&quot;a &lt; b&quot;)</td></tr>
+<tr><td class="hl-num" data-line="9"></td><td>}</td></tr>
+`
+
+// TestParseRows tests recovering line numbers and plain text from markup
+func TestParseRows(t *testing.T) {
+	expected := []Line{
+		{Number: 7, Text: "func main() {"},
+		{Number: 8, Text: "\tfmt.Println(\"a < b\")"},
+		{Number: 9, Text: "}"},
+	}
+
+	lines := ParseRows(sampleRows)
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, lines)
+	}
+}
+
+// TestLineRanges tests mapping regexp offsets to per-line character ranges
+func TestLineRanges(t *testing.T) {
+	lines := ParseRows(sampleRows)
+	text := PlainText(lines)
+
+	testCases := []struct {
+		pattern  string
+		expected []Match
+	}{
+		{
+			pattern:  `main|Println`,
+			expected: []Match{{Line: 7, Start: 5, End: 9}, {Line: 8, Start: 5, End: 12}},
+		},
+		{
+			pattern:  `\{\n\s*fmt`,
+			expected: []Match{{Line: 7, Start: 12, End: 13}, {Line: 8, Start: 0, End: 4}},
+		},
+		{
+			pattern:  `x*`,
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern, func(t *testing.T) {
+			offsets := regexp.MustCompile(tc.pattern).FindAllStringIndex(text, -1)
+			matches := Matches(LineRanges(lines, offsets))
+			if !reflect.DeepEqual(matches, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, matches)
+			}
+		})
+	}
+}
+
+// TestWindows tests merging of context windows around matched lines
+func TestWindows(t *testing.T) {
+	testCases := []struct {
+		name     string
+		matched  []int
+		context  int
+		total    int
+		expected [][2]int
+	}{
+		{"No context", []int{1, 2, 5}, 0, 10, [][2]int{{1, 2}, {5, 5}}},
+		{"Overlapping context", []int{2, 5}, 1, 10, [][2]int{{1, 6}}},
+		{"Clamped at edges", []int{0, 9}, 2, 10, [][2]int{{0, 2}, {7, 9}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			windows := Windows(tc.matched, tc.context, tc.total)
+			if !reflect.DeepEqual(windows, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, windows)
+			}
+		})
+	}
+}
+
+// TestRenderRows tests that rendered rows escape text and wrap matches
+func TestRenderRows(t *testing.T) {
+	lines := []Line{{Number: 3, Text: "a < b"}}
+	html := RenderRows(lines, map[int][]Range{3: {{Start: 4, End: 5}}})
+
+	expected := `<tr><td class="hl-num" data-line="3"></td><td>a &lt; <mark>b</mark></td></tr>` + "\n"
+	if html != expected {
+		t.Errorf("Expected %q, got %q", expected, html)
+	}
+}
+
+// TestLocate tests finding the line span and marks of a highlighted snippet
+func TestLocate(t *testing.T) {
+	markup := `<tr><td class="hl-num" data-line="41"></td><td><span class="hl-k">func</span> <mark>handle</span><span>Search</mark>(w)</td></tr>
+<tr><td class="hl-num" data-line="42"></td><td>	x := &quot;<mark><mark>a</mark></mark>&quot; + <mark>b</td></tr>
+<tr><td class="hl-num" data-line="43"></td><td>c</mark></td></tr>
+`
+
+	expected := Position{
+		StartLine: 41,
+		EndLine:   43,
+		Matches: []Match{
+			{Line: 41, Start: 5, End: 17},
+			{Line: 42, Start: 7, End: 8},
+			{Line: 42, Start: 12, End: 13},
+			{Line: 43, Start: 0, End: 1},
+		},
+	}
+
+	if position := Locate(markup); !reflect.DeepEqual(position, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, position)
+	}
+}
+
+// TestWithContext tests rebuilding snippets with surrounding lines
+func TestWithContext(t *testing.T) {
+	var document string
+	for i := 1; i <= 10; i++ {
+		document += fmt.Sprintf(`<tr><td class="hl-num" data-line="%d"></td><td>line %d</td></tr>`+"\n", i, i)
+	}
+
+	snippets := []string{
+		`<tr><td class="hl-num" data-line="3"></td><td>line <mark>3</mark></td></tr>` + "\n",
+		`<tr><td class="hl-num" data-line="5"></td><td>line <mark>5</mark></td></tr>` + "\n" +
+			`<tr><td class="hl-num" data-line="6"></td><td>line 6</td></tr>` + "\n",
+		`<tr><td class="hl-num" data-line="10"></td><td>line <mark>10</mark></td></tr>` + "\n",
+	}
+
+	result := WithContext(snippets, document, 1)
+	if len(result) != 2 {
+		t.Fatalf("Expected 2 merged snippets, got %d: %q", len(result), result)
+	}
+
+	first := Locate(result[0])
+	if first.StartLine != 2 || first.EndLine != 6 || len(first.Matches) != 2 {
+		t.Errorf("Expected lines 2-6 with 2 matches, got %+v", first)
+	}
+
+	second := Locate(result[1])
+	if second.StartLine != 9 || second.EndLine != 10 || len(second.Matches) != 1 {
+		t.Errorf("Expected lines 9-10 with 1 match, got %+v", second)
+	}
+}
+
+// TestToText tests converting a snippet to plain text with match offsets
+func TestToText(t *testing.T) {
+	markup := `<tr><td class="hl-num" data-line="8"></td><td>a &lt; <mark>b</mark></td></tr>
+<tr><td class="hl-num" data-line="9"></td><td><mark>é</mark>x</td></tr>
+`
+
+	expected := Text{
+		Text:      "a < b\néx",
+		StartLine: 8,
+		EndLine:   9,
+		Matches:   []Range{{Start: 4, End: 5}, {Start: 6, End: 7}},
+	}
+
+	if text := ToText(markup); !reflect.DeepEqual(text, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, text)
+	}
+}
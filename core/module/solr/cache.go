@@ -0,0 +1,86 @@
+package solr
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmadrosid/heline/core/module/cache"
+)
+
+// Search cache defaults. The number of entries and the TTL can be set with
+// the SEARCH_CACHE_SIZE and SEARCH_CACHE_TTL environment variables, where
+// a size of 0 turns the cache off.
+const (
+	defaultSearchCacheSize  = 1000
+	defaultSearchCacheTTL   = 5 * time.Minute
+	defaultSearchCacheBytes = 64 << 20
+)
+
+var searchCache = cache.New(searchCacheSize(), defaultSearchCacheBytes, searchCacheTTL())
+
+func searchCacheSize() int {
+	if env := os.Getenv("SEARCH_CACHE_SIZE"); env != "" {
+		if size, err := strconv.Atoi(env); err == nil && size >= 0 {
+			return size
+		}
+	}
+	return defaultSearchCacheSize
+}
+
+func searchCacheTTL() time.Duration {
+	if env := os.Getenv("SEARCH_CACHE_TTL"); env != "" {
+		if ttl, err := time.ParseDuration(env); err == nil && ttl > 0 {
+			return ttl
+		}
+	}
+	return defaultSearchCacheTTL
+}
+
+// InvalidateSearchCache drops every cached search response. It is called
+// whenever the index changes.
+func InvalidateSearchCache() {
+	searchCache.Purge()
+}
+
+// SearchCacheStats reports the search cache hits, misses and size.
+func SearchCacheStats() cache.Stats {
+	return searchCache.Stats()
+}
+
+// searchCacheKey identifies query on the Solr at solrBaseURL. Whitespace in
+// the query text and the order of filters do not change the results, so
+// they are normalized away.
+func searchCacheKey(solrBaseURL string, query SolrQuery) string {
+	query.Query = strings.Join(strings.Fields(query.Query), " ")
+	query.Expression = strings.Join(strings.Fields(query.Expression), " ")
+	query.HighlightQuery = strings.Join(strings.Fields(query.HighlightQuery), " ")
+
+	filter := append([]string(nil), query.Filter...)
+	sort.Strings(filter)
+	query.Filter = filter
+
+	// Maps are marshalled with sorted keys, so the key is deterministic
+	key, _ := json.Marshal(struct {
+		URL   string
+		Query SolrQuery
+	}{solrBaseURL, query})
+	return string(key)
+}
+
+// isPartial reports whether a search response was cut short by
+// timeAllowed, which must not be cached.
+func isPartial(body []byte) bool {
+	var data struct {
+		Header struct {
+			PartialResults bool `json:"partialResults"`
+		} `json:"responseHeader"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return true
+	}
+	return data.Header.PartialResults
+}
@@ -0,0 +1,80 @@
+package solr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// solrClient sends every request to Solr. It has no overall timeout since
+// exports stream for as long as the caller reads them; requests are bounded
+// by their context instead.
+var solrClient = &http.Client{}
+
+// RequestTimeout bounds a Solr request whose context has no deadline of its
+// own. SetupTimeout does the same for schema setup and index resets, which
+// send many requests.
+const (
+	RequestTimeout = 30 * time.Second
+	SetupTimeout   = 2 * time.Minute
+)
+
+// withTimeout returns ctx bounded by timeout, unless it already has an
+// earlier deadline.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return solrClient.Do(req)
+}
+
+// maxErrorBody caps how much of an error response is read.
+const maxErrorBody = 64 << 10
+
+// Error is a non-2xx response from Solr.
+type Error struct {
+	Status int
+	// Message is Solr's error message, or the status text when the
+	// response has none.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("solr returned status %d: %s", e.Status, e.Message)
+}
+
+// checkResponse returns an *Error for a non-2xx response, with the message
+// from Solr's {"error":{"msg":...}} body. It reads the body in that case,
+// so the caller must not use it afterwards.
+func checkResponse(res *http.Response) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+
+	err := &Error{
+		Status:  res.StatusCode,
+		Message: http.StatusText(res.StatusCode),
+	}
+
+	var data struct {
+		Error struct {
+			Msg string `json:"msg"`
+		} `json:"error"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+	if json.Unmarshal(body, &data) == nil && data.Error.Msg != "" {
+		err.Message = data.Error.Msg
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package solr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+)
+
+// documentFields are the fields returned for each fetched document.
+const documentFields = "id,file_id,repo,lang,path,branch,owner_id,content"
+
+// GetDocument fetches a single indexed file by its Solr id, which Solr
+// returns under doc.
+func GetDocument(ctx context.Context, id string) ([]byte, error) {
+	return GetDocuments(ctx, []string{id})
+}
+
+// GetDocuments fetches indexed files by id in one request to the real-time
+// get handler, so chunks appended by the indexer are returned even before
+// the next commit. Solr lists them under response.docs, except for a
+// single id which it returns as doc. Ids that do not exist are left out.
+func GetDocuments(ctx context.Context, ids []string) ([]byte, error) {
+	ctx, cancel := withTimeout(ctx, RequestTimeout)
+	defer cancel()
+
+	// Get Solr URL from environment variables or use default
+	solrBaseURL := os.Getenv("SOLR_BASE_URL")
+	if solrBaseURL == "" {
+		solrBaseURL = "http://localhost:8984"
+	}
+
+	u, err := url.Parse(fmt.Sprintf("%s/solr/heline/get", solrBaseURL))
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	for _, id := range ids {
+		q.Add("id", id)
+	}
+	q.Set("fl", documentFields)
+	q.Set("wt", "json")
+	u.RawQuery = q.Encode()
+
+	res, err := get(ctx, u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if err := checkResponse(res); err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(res.Body)
+}
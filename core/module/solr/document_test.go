@@ -0,0 +1,49 @@
+package solr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// TestGetDocument tests that GetDocument calls the real-time get handler
+func TestGetDocument(t *testing.T) {
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/solr/heline/get" {
+			t.Errorf("Expected request to /solr/heline/get, got %s", r.URL.Path)
+		}
+
+		if id := r.URL.Query().Get("id"); id != "ahmadrosid/heline/main.go" {
+			t.Errorf("Expected id=ahmadrosid/heline/main.go, got %s", id)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"doc":{"id":"ahmadrosid/heline/main.go","repo":"ahmadrosid/heline","content":["<tr>1</tr>\n","<tr>2</tr>\n"]}}`)
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+
+	result, err := GetDocument(context.Background(), "ahmadrosid/heline/main.go")
+	if err != nil {
+		t.Fatalf("GetDocument failed: %v", err)
+	}
+
+	var data struct {
+		Doc struct {
+			Content []string `json:"content"`
+		} `json:"doc"`
+	}
+	if err := json.Unmarshal(result, &data); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if len(data.Doc.Content) != 2 {
+		t.Errorf("Expected 2 content chunks, got %d", len(data.Doc.Content))
+	}
+}
@@ -0,0 +1,61 @@
+package solr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// exportFields are returned by the export handler. It can only return
+// fields with docValues, so content and highlighting are not available.
+const exportFields = "id,file_id,repo,lang,path,branch,owner_id"
+
+// Export streams every document matching query from Solr's /export
+// handler, in the query's sort order. The export handler cannot sort by
+// score, so relevance order falls back to id. The caller must close the
+// returned body, which holds the usual {"response":{"docs":[...]}} JSON
+// and should be decoded incrementally.
+func Export(ctx context.Context, query SolrQuery) (io.ReadCloser, error) {
+	// Get Solr URL from environment variables or use default
+	solrBaseURL := os.Getenv("SOLR_BASE_URL")
+	if solrBaseURL == "" {
+		solrBaseURL = "http://localhost:8984"
+	}
+
+	solrQuery, _ := buildQuery(query)
+
+	form := url.Values{}
+	form.Set("q", solrQuery)
+	form.Set("defType", "edismax")
+	form.Set("qf", queryFields())
+	sort := "id asc"
+	if query.Sort != "" && query.Sort != DefaultSort {
+		sort = sortOrder(query.Sort)
+	}
+	form.Set("sort", sort)
+	form.Set("fl", exportFields)
+	for _, filter := range query.Filter {
+		form.Add("fq", filter)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/solr/heline/export", solrBaseURL), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := solrClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(res); err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+
+	return res.Body, nil
+}
@@ -1,7 +1,7 @@
 package solr
 
 import (
-	"fmt"
+	"context"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -9,28 +9,34 @@ import (
 
 type Map map[string]interface{}
 
-func Insert(payload io.Reader) error {
+func Insert(ctx context.Context, payload io.Reader) error {
+	ctx, cancel := withTimeout(ctx, RequestTimeout)
+	defer cancel()
 
 	url := "http://localhost:8984/solr/heline/update?&commitWithin=1000&overwrite=true&wt=json"
 	// url := "http://heline.dev:8984/solr/heline/update?&commitWithin=1000&overwrite=true&wt=json"
 
-	req, err := http.NewRequest("POST", url, payload)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, payload)
 	if err != nil {
-		println(err.Error())
 		return err
 	}
 
 	req.Header.Add("Content-type", "application/json")
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := solrClient.Do(req)
 	if err != nil {
-		println(err.Error())
 		return err
 	}
 
 	defer res.Body.Close()
-	body, _ := ioutil.ReadAll(res.Body)
+	if err := checkResponse(res); err != nil {
+		return err
+	}
+	if _, err := io.Copy(ioutil.Discard, res.Body); err != nil {
+		return err
+	}
+
+	InvalidateSearchCache()
 
-	fmt.Println(string(body))
 	return nil
 }
@@ -0,0 +1,44 @@
+//go:build go1.18
+// +build go1.18
+
+package lucene
+
+import "testing"
+
+// Fuzz tests need Go 1.18. Run them with, for example:
+//
+//	go test ./core/module/solr/lucene -fuzz=FuzzTerm
+
+// FuzzTerm tests escaping arbitrary text as a term
+func FuzzTerm(f *testing.F) {
+	for _, input := range hostileInputs {
+		f.Add(input)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		checkEscaped(t, s)
+		checkStructure(t, Term("content", s).String())
+		checkStructure(t, Contains("content", s).String())
+	})
+}
+
+// FuzzPhrase tests quoting arbitrary text as a phrase
+func FuzzPhrase(f *testing.F) {
+	for _, input := range hostileInputs {
+		f.Add(input)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		checkPhrase(t, s)
+	})
+}
+
+// FuzzBoolean tests combining clauses built from arbitrary text
+func FuzzBoolean(f *testing.F) {
+	for i, input := range hostileInputs {
+		f.Add(input, hostileInputs[(i+1)%len(hostileInputs)])
+	}
+	f.Fuzz(func(t *testing.T, a, b string) {
+		q := Or(Boost(Phrase("", a), 10), And(Term("", a), Not(Term("content", b))), Join(Term("", b), Phrase("content", a)))
+		checkStructure(t, q.String())
+		checkStructure(t, Build(q))
+	})
+}
@@ -0,0 +1,243 @@
+package lucene
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// specialChars are the characters with meaning in the standard Lucene
+// query parser. Whitespace, which separates clauses, is escaped too.
+const specialChars = `\+-!():^[]"{}~*?|&/`
+
+// keywords are the words the query parsers read as operators. Solr treats
+// them as operators regardless of case when lowercaseOperators is on.
+var keywords = []string{"AND", "OR", "NOT", "TO"}
+
+// Escape backslash-escapes every Lucene special character and whitespace
+// in s. A value that is an operator keyword has its first letter escaped
+// so it is searched as a term. Other bytes, including invalid UTF-8, are
+// kept as they are.
+func Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if strings.ContainsRune(specialChars, c) || unicode.IsSpace(c) || i == 0 && isKeyword(s) {
+			b.WriteByte('\\')
+		}
+		b.WriteString(s[i : i+size])
+		i += size
+	}
+	return b.String()
+}
+
+// EscapePhrase escapes s for use inside a double-quoted phrase, where only
+// quotes and backslashes have meaning.
+func EscapePhrase(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
+
+func isKeyword(s string) bool {
+	for _, keyword := range keywords {
+		if strings.EqualFold(s, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// Query is a clause of a query in the standard Lucene syntax, which the
+// lucene and edismax parsers accept. Queries are built from these clauses
+// rather than by formatting strings, so user text is always escaped and
+// can never change the structure of a query. String returns the query;
+// an empty string means no clause.
+type Query interface {
+	String() string
+}
+
+// withField prefixes clause with field, unless field is empty so the
+// clause is searched across the parser's default or query fields.
+func withField(field, clause string) string {
+	if field == "" {
+		return clause
+	}
+	return Escape(field) + ":" + clause
+}
+
+type term struct {
+	field string
+	value string
+}
+
+// Term matches value as a single term of field. Field may be empty.
+// An empty value matches nothing.
+func Term(field, value string) Query {
+	return term{field, value}
+}
+
+func (t term) String() string {
+	if t.value == "" {
+		return withField(t.field, `""`)
+	}
+	return withField(t.field, Escape(t.value))
+}
+
+type phrase struct {
+	field string
+	value string
+}
+
+// Phrase matches the words of value next to each other and in order in
+// field. Field may be empty.
+func Phrase(field, value string) Query {
+	return phrase{field, value}
+}
+
+func (p phrase) String() string {
+	return withField(p.field, `"`+EscapePhrase(p.value)+`"`)
+}
+
+type wildcard struct {
+	field    string
+	value    string
+	leading  bool
+	trailing bool
+}
+
+// Prefix matches the terms of field that start with value.
+func Prefix(field, value string) Query {
+	return wildcard{field, value, false, true}
+}
+
+// Suffix matches the terms of field that end with value.
+func Suffix(field, value string) Query {
+	return wildcard{field, value, true, false}
+}
+
+// Contains matches the terms of field that contain value.
+//
+// Wildcard terms skip the field's tokenizer, so the value of Prefix, Suffix
+// and Contains should be a single token. An empty value matches nothing.
+func Contains(field, value string) Query {
+	return wildcard{field, value, true, true}
+}
+
+func (w wildcard) String() string {
+	if w.value == "" {
+		return withField(w.field, `""`)
+	}
+
+	s := Escape(w.value)
+	if w.leading {
+		s = "*" + s
+	}
+	if w.trailing {
+		s += "*"
+	}
+	return withField(w.field, s)
+}
+
+type boolean struct {
+	operator string
+	clauses  []Query
+}
+
+// And matches documents matching every clause.
+func And(clauses ...Query) Query {
+	return boolean{" AND ", clauses}
+}
+
+// Or matches documents matching any clause.
+func Or(clauses ...Query) Query {
+	return boolean{" OR ", clauses}
+}
+
+// Join combines clauses with the parser's default operator, q.op, and
+// lets edismax apply its minimum-should-match rules.
+func Join(clauses ...Query) Query {
+	return boolean{" ", clauses}
+}
+
+func (b boolean) String() string {
+	if len(b.parts()) > 1 {
+		return "(" + b.join() + ")"
+	}
+	return b.join()
+}
+
+// join returns the clauses of b joined by its operator.
+func (b boolean) join() string {
+	return strings.Join(b.parts(), b.operator)
+}
+
+// parts returns the clauses of b that are not empty.
+func (b boolean) parts() []string {
+	var parts []string
+	for _, clause := range b.clauses {
+		if clause == nil {
+			continue
+		}
+		if s := clause.String(); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return parts
+}
+
+// Build returns q as a top-level query. Unlike String it leaves out the
+// parentheses around a boolean query, so edismax applies mm to its clauses.
+func Build(q Query) string {
+	if b, ok := q.(boolean); ok {
+		return b.join()
+	}
+	return q.String()
+}
+
+type not struct {
+	clause Query
+}
+
+// Not excludes the documents matching clause. Lucene cannot match a purely
+// negative query, so use it as a clause of And next to a positive one.
+func Not(clause Query) Query {
+	return not{clause}
+}
+
+func (n not) String() string {
+	s := n.clause.String()
+	if s == "" {
+		return ""
+	}
+	return "-" + s
+}
+
+type boost struct {
+	clause Query
+	weight float64
+}
+
+// Boost multiplies the score of documents matching clause by weight.
+func Boost(clause Query, weight float64) Query {
+	return boost{clause, weight}
+}
+
+func (b boost) String() string {
+	s := b.clause.String()
+	if s == "" {
+		return ""
+	}
+	return s + "^" + strconv.FormatFloat(b.weight, 'f', -1, 64)
+}
+
+type matchAll struct{}
+
+// MatchAll matches every document.
+func MatchAll() Query {
+	return matchAll{}
+}
+
+func (matchAll) String() string {
+	return "*:*"
+}
@@ -0,0 +1,202 @@
+package lucene
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+// hostileInputs are values that break or alter naively built queries.
+var hostileInputs = []string{
+	`foo"bar`,
+	`C:\path\to`,
+	`a && b || c`,
+	`http://example.com/x`,
+	`AND`,
+	`or`,
+	`"unterminated`,
+	`(((`,
+	`\`,
+	`func(a, b) {}`,
+	`x^2~3*?`,
+	`tab	and
+newline`,
+	`content:secret`,
+	`*:*`,
+	`{!lucene}x`,
+}
+
+// TestEscape tests escaping of single terms
+func TestEscape(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`handleSearch`, `handleSearch`},
+		{`a:b`, `a\:b`},
+		{`foo bar`, `foo\ bar`},
+		{`"quoted"`, `\"quoted\"`},
+		{`back\slash`, `back\\slash`},
+		{`a&&b||c`, `a\&\&b\|\|c`},
+		{`a/b`, `a\/b`},
+		{`AND`, `\AND`},
+		{`not`, `\not`},
+		{`ANDROID`, `ANDROID`},
+		{`ünïcode`, `ünïcode`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			if got := Escape(tc.input); got != tc.expected {
+				t.Errorf("Escape(%q) = %q, expected %q", tc.input, got, tc.expected)
+			}
+		})
+	}
+}
+
+// TestQuery tests building queries from clauses
+func TestQuery(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    Query
+		expected string
+	}{
+		{"Term", Term("content", "a:b"), `content:a\:b`},
+		{"Term without field", Term("", "AND"), `\AND`},
+		{"Empty term", Term("content", ""), `content:""`},
+		{"Prefix", Prefix("content", "run"), `content:run*`},
+		{"Suffix", Suffix("content", "a:b"), `content:*a\:b`},
+		{"Contains", Contains("content", "a*b"), `content:*a\*b*`},
+		{"Contains keyword", Contains("content", "or"), `content:*\or*`},
+		{"Empty contains", Contains("content", ""), `content:""`},
+		{"Phrase", Phrase("content", `say "hi" \o/`), `content:"say \"hi\" \\o/"`},
+		{"Or", Or(Term("", "a"), Term("", "b")), `(a OR b)`},
+		{"And with Not", And(Term("", "a"), Not(Term("lang", "go"))), `(a AND -lang:go)`},
+		{"Single clause", And(Term("", "a")), `a`},
+		{"Empty clauses", Or(nil, Or(), Term("", "a")), `a`},
+		{"Nested", And(Or(Term("", "a"), Term("", "b")), Term("", "c")), `((a OR b) AND c)`},
+		{"Boost", Boost(Phrase("", "a b"), 10), `"a b"^10`},
+		{"Fractional boost", Boost(Or(Term("", "a"), Term("", "b")), 0.5), `(a OR b)^0.5`},
+		{"Join", Join(Term("", "a"), Term("", "b")), `(a b)`},
+		{"Match all", MatchAll(), `*:*`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.query.String(); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestBuild tests that top-level boolean queries are not parenthesized
+func TestBuild(t *testing.T) {
+	if got := Build(Join(Term("", "a"), Term("", "b"))); got != `a b` {
+		t.Errorf("Expected %q, got %q", `a b`, got)
+	}
+	if got := Build(Term("content", "a b")); got != `content:a\ b` {
+		t.Errorf("Expected %q, got %q", `content:a\ b`, got)
+	}
+}
+
+// TestHostileInputs tests that user text never changes the query structure
+func TestHostileInputs(t *testing.T) {
+	for _, input := range hostileInputs {
+		t.Run(input, func(t *testing.T) {
+			checkEscaped(t, input)
+			checkPhrase(t, input)
+			checkStructure(t, Build(Or(Boost(Phrase("", input), 10), And(Term("", input), Not(Term("content", input))))))
+			checkStructure(t, And(Prefix("content", input), Suffix("", input), Contains("content", input)).String())
+		})
+	}
+}
+
+// checkEscaped checks that Escape leaves no special character unescaped,
+// never yields a bare operator keyword, and loses nothing.
+func checkEscaped(t *testing.T, s string) {
+	escaped := Escape(s)
+
+	var unescaped strings.Builder
+	for i := 0; i < len(escaped); {
+		c, size := utf8.DecodeRuneInString(escaped[i:])
+		if c == '\\' {
+			if i+size == len(escaped) {
+				t.Fatalf("Escape(%q) = %q ends with a lone backslash", s, escaped)
+			}
+			i += size
+			_, size = utf8.DecodeRuneInString(escaped[i:])
+		} else if isSpecial(c) {
+			t.Fatalf("Escape(%q) = %q leaves %q unescaped", s, escaped, c)
+		}
+		unescaped.WriteString(escaped[i : i+size])
+		i += size
+	}
+
+	if unescaped.String() != s {
+		t.Fatalf("Escape(%q) = %q unescapes to %q", s, escaped, unescaped.String())
+	}
+	if isKeyword(escaped) {
+		t.Fatalf("Escape(%q) = %q is an operator", s, escaped)
+	}
+}
+
+// checkPhrase checks that a phrase cannot be closed early and keeps its
+// value.
+func checkPhrase(t *testing.T, s string) {
+	q := Phrase("content", s).String()
+	if !strings.HasPrefix(q, `content:"`) || !strings.HasSuffix(q, `"`) {
+		t.Fatalf("Phrase(%q) = %q is not a quoted phrase", s, q)
+	}
+
+	inner := strings.TrimSuffix(strings.TrimPrefix(q, `content:"`), `"`)
+	var unescaped strings.Builder
+	for i := 0; i < len(inner); i++ {
+		switch inner[i] {
+		case '\\':
+			if i+1 == len(inner) {
+				t.Fatalf("Phrase(%q) = %q escapes its closing quote", s, q)
+			}
+			i++
+		case '"':
+			t.Fatalf("Phrase(%q) = %q closes early", s, q)
+		}
+		unescaped.WriteByte(inner[i])
+	}
+
+	if unescaped.String() != s {
+		t.Fatalf("Phrase(%q) = %q unescapes to %q", s, q, unescaped.String())
+	}
+}
+
+// checkStructure checks that the quotes and parentheses of q, ignoring
+// escaped characters, are balanced.
+func checkStructure(t *testing.T, q string) {
+	depth := 0
+	quoted := false
+	for i := 0; i < len(q); i++ {
+		switch c := q[i]; {
+		case c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth < 0 {
+				t.Fatalf("Query %q closes a group it did not open", q)
+			}
+		}
+	}
+
+	if quoted || depth != 0 {
+		t.Fatalf("Query %q leaves a phrase or group open", q)
+	}
+}
+
+func isSpecial(c rune) bool {
+	return strings.ContainsRune(specialChars, c) || unicode.IsSpace(c)
+}
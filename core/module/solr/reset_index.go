@@ -2,6 +2,7 @@ package solr
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -12,7 +13,14 @@ import (
 // ResetIndex completely resets the Solr index by:
 // 1. Deleting all documents
 // 2. Optionally recreating the schema
-func ResetIndex(recreateSchema bool) error {
+//
+// It is a variable so HTTP handler tests can replace it with a stub.
+var ResetIndex = resetIndex
+
+func resetIndex(ctx context.Context, recreateSchema bool) error {
+	ctx, cancel := withTimeout(ctx, SetupTimeout)
+	defer cancel()
+
 	// Get Solr URL from environment variables or use default
 	solrBaseURL := os.Getenv("SOLR_BASE_URL")
 	if solrBaseURL == "" {
@@ -21,23 +29,26 @@ func ResetIndex(recreateSchema bool) error {
 
 	fmt.Println("🧹 Resetting Solr index...")
 
+	// Cached results are stale even if the reset fails part way
+	defer InvalidateSearchCache()
+
 	// Step 1: Delete all documents
-	if err := deleteAllDocuments(solrBaseURL); err != nil {
+	if err := deleteAllDocuments(ctx, solrBaseURL); err != nil {
 		return fmt.Errorf("failed to delete all documents: %w", err)
 	}
 
 	// Step 2: Unload the core (optional)
 	if recreateSchema {
-		if err := unloadCore(solrBaseURL); err != nil {
+		if err := unloadCore(ctx, solrBaseURL); err != nil {
 			return fmt.Errorf("failed to unload core: %w", err)
 		}
 
 		// Step 3: Recreate the core and schema
-		if err := createCores(solrBaseURL); err != nil {
+		if err := createCores(ctx, solrBaseURL); err != nil {
 			return fmt.Errorf("failed to create Solr cores: %w", err)
 		}
 
-		if err := setupHelineSchema(solrBaseURL); err != nil {
+		if err := setupHelineSchema(ctx, solrBaseURL); err != nil {
 			return fmt.Errorf("failed to set up heline schema: %w", err)
 		}
 	}
@@ -47,7 +58,7 @@ func ResetIndex(recreateSchema bool) error {
 }
 
 // deleteAllDocuments removes all documents from the Solr index
-func deleteAllDocuments(solrBaseURL string) error {
+func deleteAllDocuments(ctx context.Context, solrBaseURL string) error {
 	fmt.Println("Deleting all documents from index...")
 	
 	// Construct the delete-all query
@@ -65,18 +76,20 @@ func deleteAllDocuments(solrBaseURL string) error {
 	
 	// Send the delete request
 	url := fmt.Sprintf("%s/solr/heline/update", solrBaseURL)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(deleteJSON))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(deleteJSON))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := solrClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
 	
 	body, _ := ioutil.ReadAll(resp.Body)
 	fmt.Println("Delete response:", string(body))
@@ -85,13 +98,13 @@ func deleteAllDocuments(solrBaseURL string) error {
 }
 
 // unloadCore unloads the Solr core
-func unloadCore(solrBaseURL string) error {
+func unloadCore(ctx context.Context, solrBaseURL string) error {
 	fmt.Println("Unloading Solr core...")
 	
 	// Construct the unload URL with parameters to delete the data
 	unloadURL := fmt.Sprintf("%s/solr/admin/cores?action=UNLOAD&core=heline&deleteIndex=true&deleteDataDir=true&deleteInstanceDir=true", solrBaseURL)
 	
-	resp, err := http.Get(unloadURL)
+	resp, err := get(ctx, unloadURL)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,7 @@
 package solr
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -81,6 +82,16 @@ func TestResetIndex(t *testing.T) {
 			w.WriteHeader(http.StatusOK)
 			fmt.Fprintln(w, `{"responseHeader":{"status":0,"QTime":10}}`)
 
+		// Config overlay endpoint, empty until the spellcheck is added
+		case path == "/solr/heline/config/overlay":
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, `{"responseHeader":{"status":0,"QTime":1},"overlay":{}}`)
+
+		// Config update endpoint
+		case path == "/solr/heline/config" && method == "POST":
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, `{"responseHeader":{"status":0,"QTime":10}}`)
+
 		default:
 			t.Logf("Unexpected request to %s with method %s", path, method)
 			w.WriteHeader(http.StatusNotFound)
@@ -109,7 +120,7 @@ func TestResetIndex(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := ResetIndex(tc.recreateSchema)
+			err := ResetIndex(context.Background(), tc.recreateSchema)
 			if err != nil {
 				t.Errorf("ResetIndex(%v) failed: %v", tc.recreateSchema, err)
 			}
@@ -161,7 +172,7 @@ func TestDeleteAllDocuments(t *testing.T) {
 	defer mockServer.Close()
 
 	// Call the function with the mock server URL
-	err := deleteAllDocuments(mockServer.URL)
+	err := deleteAllDocuments(context.Background(), mockServer.URL)
 	if err != nil {
 		t.Errorf("deleteAllDocuments failed: %v", err)
 	}
@@ -206,7 +217,7 @@ func TestUnloadCore(t *testing.T) {
 	defer mockServer.Close()
 
 	// Call the function with the mock server URL
-	err := unloadCore(mockServer.URL)
+	err := unloadCore(context.Background(), mockServer.URL)
 	if err != nil {
 		t.Errorf("unloadCore failed: %v", err)
 	}
@@ -2,29 +2,212 @@ package solr
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/ahmadrosid/heline/core/entity"
-	"github.com/ahmadrosid/heline/core/utils"
+	"github.com/ahmadrosid/heline/core/module/solr/lucene"
 )
 
 type SolrQuery struct {
 	Query  string
 	Filter []string
+	// Offset and Limit select a page of results. Limit 0 keeps Solr's default.
+	Offset int
+	Limit  int
+	// Cursor enables deep paging with Solr's cursorMark. Use "*" for the
+	// first page and the returned nextCursorMark afterwards. Offset is
+	// ignored when a cursor is set, and cursor pages are not cached.
+	Cursor string
+	// Expression is a prebuilt query in Lucene syntax; terms without a field
+	// are searched across the query fields. When it is set, Query is
+	// ignored and HighlightQuery is used for hl.q.
+	Expression     string
+	HighlightQuery string
+	// WithContent returns the stored content chunks of each document and
+	// turns highlighting off, for callers that verify matches themselves.
+	WithContent bool
+	// NoHighlight turns highlighting off for searches that do not match
+	// the content, such as file path searches.
+	NoHighlight bool
+	// Sort is one of the keys of SortOrders. Empty means relevance.
+	Sort string
+	// FacetLimits overrides the number of buckets returned per facet field,
+	// on top of DefaultFacetLimits.
+	FacetLimits map[string]int
+	// Dedupe collapses documents with the same content_hash into the best
+	// scoring one, and lists the others under expanded in the response.
+	// Documents indexed without a hash are never collapsed. Export ignores
+	// it since the export handler cannot collapse.
+	Dedupe bool
+	// Debug asks Solr to explain the score of each hit and time each
+	// search component, and to echo the request params. Debug responses
+	// are never cached.
+	Debug bool
 }
 
-func Search(query SolrQuery) ([]byte, error) {
+// DedupeLocations is the number of collapsed duplicates listed per hit.
+const DedupeLocations = 10
+
+// FacetFields are the fields Search returns term facets for, in order.
+var FacetFields = []string{"lang", "path", "repo", "branch", "owner_id"}
+
+// DefaultFacetLimits are the number of buckets returned per facet field.
+var DefaultFacetLimits = map[string]int{
+	"lang":     10,
+	"path":     8,
+	"repo":     7,
+	"branch":   5,
+	"owner_id": 7,
+}
+
+// TagFilter tags a filter on field so it is excluded when counting that
+// field's facet. This keeps the counts of unselected values, so a UI can
+// offer "Go (120), Rust (45)" while Go is selected.
+func TagFilter(field, filter string) string {
+	return fmt.Sprintf("{!tag=%s}%s", field, filter)
+}
+
+// facets builds the JSON facet request for FacetFields. Each facet
+// ignores the filters tagged with its own field.
+func facets(limits map[string]int) entity.Map {
+	facet := entity.Map{}
+	for _, field := range FacetFields {
+		limit := DefaultFacetLimits[field]
+		if l, ok := limits[field]; ok && l > 0 {
+			limit = l
+		}
+
+		facet[field] = entity.Map{
+			"type":  "terms",
+			"field": field,
+			"limit": limit,
+			"domain": entity.Map{
+				"excludeTags": field,
+			},
+		}
+	}
+	return facet
+}
+
+// DefaultSort is the sort used when SolrQuery.Sort is empty.
+const DefaultSort = "relevance"
+
+// SortOrders maps sort names to Solr sort clauses. Every order ends on id
+// so ties are broken deterministically, which cursorMark also requires.
+var SortOrders = map[string]string{
+	"relevance":  "score desc,id asc",
+	"path":       "path asc,id asc",
+	"repo":       "repo asc,id asc",
+	"indexed_at": "indexed_at desc,id asc",
+}
+
+// sortOrder returns the Solr sort clause for name, falling back to
+// relevance for unknown names.
+func sortOrder(name string) string {
+	if order, ok := SortOrders[name]; ok {
+		return order
+	}
+	return SortOrders[DefaultSort]
+}
+
+// DefaultFieldBoosts are the fields that terms without an explicit field
+// are matched against, and their weights. code_content matches token
+// sequences and identifier_ngram matches partial identifiers. They can be
+// replaced with the SOLR_QUERY_FIELDS environment variable, using Solr's qf
+// syntax such as "content^10 identifier_ngram^2", which is the only way to
+// tune them. A boost of 0 leaves the field out.
+var DefaultFieldBoosts = map[string]float64{
+	"content":          10,
+	"code_content":     5,
+	"identifier_ngram": 1,
+}
+
+// phraseFields boosts documents where all terms appear close together.
+// identifier_ngram is left out since phrases over n-grams are meaningless.
+const phraseFields = "content^20 code_content^10"
+
+// queryFields returns the edismax qf parameter.
+func queryFields() string {
+	boosts := map[string]float64{}
+	if env := os.Getenv("SOLR_QUERY_FIELDS"); env != "" {
+		for _, field := range strings.Fields(env) {
+			name, boost := field, 1.0
+			if i := strings.Index(field, "^"); i >= 0 {
+				name = field[:i]
+				if b, err := strconv.ParseFloat(field[i+1:], 64); err == nil {
+					boost = b
+				}
+			}
+			boosts[name] = boost
+		}
+	} else {
+		for name, boost := range DefaultFieldBoosts {
+			boosts[name] = boost
+		}
+	}
+
+	var fields []string
+	for name, boost := range boosts {
+		if boost > 0 {
+			fields = append(fields, fmt.Sprintf("%s^%s", name, strconv.FormatFloat(boost, 'f', -1, 64)))
+		}
+	}
+	sort.Strings(fields)
+	return strings.Join(fields, " ")
+}
+
+// defaultTimeAllowed is the default of timeAllowed, in milliseconds.
+const defaultTimeAllowed = 10000
+
+// timeAllowed returns how long Solr may spend on a search, in milliseconds,
+// before it returns the hits found so far and flags them as partial. It can
+// be set with the SOLR_TIME_ALLOWED environment variable.
+func timeAllowed() int {
+	if env := os.Getenv("SOLR_TIME_ALLOWED"); env != "" {
+		if ms, err := strconv.Atoi(env); err == nil && ms > 0 {
+			return ms
+		}
+	}
+	return defaultTimeAllowed
+}
+
+// Search runs query against the heline core. The request is cancelled with
+// ctx and bounded by RequestTimeout. Complete responses are cached until
+// the index changes, see InvalidateSearchCache, except for cursor pages,
+// which are mostly read once while streaming, and debug responses.
+func Search(ctx context.Context, query SolrQuery) ([]byte, error) {
+	ctx, cancel := withTimeout(ctx, RequestTimeout)
+	defer cancel()
+
 	// Get Solr URL from environment variables or use default
 	solrBaseURL := os.Getenv("SOLR_BASE_URL")
 	if solrBaseURL == "" {
 		solrBaseURL = "http://localhost:8984"
 	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	cacheable := query.Cursor == "" && !query.Debug
+	cacheKey := searchCacheKey(solrBaseURL, query)
+	if cacheable {
+		if body, ok := searchCache.Get(cacheKey); ok {
+			return body, nil
+		}
+	}
+	// A response fetched while the cache is invalidated may predate the
+	// index change, so it is only cached if no purge happened in between
+	generation := searchCache.Generation()
 	
 	solrURL := fmt.Sprintf("%s/solr/heline/select", solrBaseURL)
 	u, _ := url.Parse(solrURL)
@@ -45,75 +228,9 @@ func Search(query SolrQuery) ([]byte, error) {
 	q.Set("hl.regex.pattern", ".*")
 	q.Set("hl.bs.type", "WORD")
 	q.Set("hl.bs.language", "en")
-	u.RawQuery = q.Encode()
 
-	// Check if the query contains special characters that might need phrase searching
-	hasSpecialChars := false
-	specialChars := []string{":", ";", "{", "}", "(", ")", "[", "]", "<", ">", "=", "+", "-", "!", "*", "?", "~", "^", "&", "|", "%"}
-	for _, char := range specialChars {
-		if strings.Contains(query.Query, char) {
-			hasSpecialChars = true
-			break
-		}
-	}
+	solrQuery, hlQuery := buildQuery(query)
 
-	// Construct the query based on content
-	var solrQuery string
-	var hlQuery string
-	
-	if hasSpecialChars {
-		// For code patterns with special characters, we need to use a more precise approach
-		// First, try an exact phrase match with proper escaping
-		escapedQuery := strings.ReplaceAll(query.Query, ":", "\\:")
-		escapedQuery = strings.ReplaceAll(escapedQuery, "(", "\\(")
-		escapedQuery = strings.ReplaceAll(escapedQuery, ")", "\\)")
-		escapedQuery = strings.ReplaceAll(escapedQuery, "[", "\\[")
-		escapedQuery = strings.ReplaceAll(escapedQuery, "]", "\\]")
-		escapedQuery = strings.ReplaceAll(escapedQuery, "{", "\\{")
-		escapedQuery = strings.ReplaceAll(escapedQuery, "}", "\\}")
-		
-		// Build a query that searches for the exact phrase and also for parts of the phrase
-		// This improves recall while still prioritizing exact matches
-		solrQuery = fmt.Sprintf("content:\"%s\"^10", query.Query)
-		
-		// Add individual term matches with lower boost
-		terms := strings.Fields(query.Query)
-		for _, term := range terms {
-			if len(term) > 1 { // Only add meaningful terms
-				// Escape the term for safe querying
-				escapedTerm := strings.ReplaceAll(term, ":", "\\:")
-				escapedTerm = strings.ReplaceAll(escapedTerm, "(", "\\(")
-				escapedTerm = strings.ReplaceAll(escapedTerm, ")", "\\)")
-				escapedTerm = strings.ReplaceAll(escapedTerm, "[", "\\[")
-				escapedTerm = strings.ReplaceAll(escapedTerm, "]", "\\]")
-				escapedTerm = strings.ReplaceAll(escapedTerm, "{", "\\{")
-				escapedTerm = strings.ReplaceAll(escapedTerm, "}", "\\}")
-				solrQuery += fmt.Sprintf(" OR content:%s^2", escapedTerm)
-			}
-		}
-		
-		// Also search for the pattern without spaces
-		noSpaceQuery := strings.ReplaceAll(query.Query, " ", "")
-		if noSpaceQuery != query.Query {
-			// Escape the no-space query as well
-			escapedNoSpace := strings.ReplaceAll(noSpaceQuery, ":", "\\:")
-			escapedNoSpace = strings.ReplaceAll(escapedNoSpace, "(", "\\(")
-			escapedNoSpace = strings.ReplaceAll(escapedNoSpace, ")", "\\)")
-			escapedNoSpace = strings.ReplaceAll(escapedNoSpace, "[", "\\[")
-			escapedNoSpace = strings.ReplaceAll(escapedNoSpace, "]", "\\]")
-			escapedNoSpace = strings.ReplaceAll(escapedNoSpace, "{", "\\{")
-			escapedNoSpace = strings.ReplaceAll(escapedNoSpace, "}", "\\}")
-			solrQuery += fmt.Sprintf(" OR content:%s^5", escapedNoSpace)
-		}
-		
-		// For highlighting, use the original query in quotes for proper phrase matching
-		hlQuery = fmt.Sprintf("content:\"%s\"", query.Query)
-	} else {
-		// Use standard query for simple terms
-		solrQuery = "content:" + query.Query
-		hlQuery = solrQuery
-	}
-	
 	// Set content field for highlighting
 	q.Set("hl.fl", "content")
 	
@@ -131,65 +248,149 @@ func Search(query SolrQuery) ([]byte, error) {
 	q.Set("hl.maxAnalyzedChars", "500000")
 	q.Set("hl.phraseLimit", "2000")
 	q.Set("hl.multiValuedSeparatorChar", " ")
-	
-	// Debug info
-	fmt.Println("\n==== SEARCH QUERY INFO ====")
-	fmt.Println("Original query:", query.Query)
-	fmt.Println("Solr query:", solrQuery)
-	fmt.Println("Highlight query:", hlQuery)
-	fmt.Println("==== END QUERY INFO ====")
+	// Hits the highlighter finds no match in still get their first lines
+	q.Set("hl.defaultSummary", "true")
+
+	if query.Debug {
+		q.Set("debugQuery", "true")
+		q.Set("echoParams", "explicit")
+	}
 
 	data := entity.Map{
 		"query":  solrQuery,
-		"fields": "id,file_id,repo,lang,branch,owner_id",
-		"facet": entity.Map{
-			"lang": entity.Map{
-				"type":  "terms",
-				"field": "lang",
-				"limit": 10,
-			},
-			"path": entity.Map{
-				"type":  "terms",
-				"field": "path",
-				"limit": 8,
-			},
-			"repo": entity.Map{
-				"type":  "terms",
-				"field": "repo",
-				"limit": 7,
-			},
-		},
+		"fields": "id,file_id,repo,lang,path,branch,owner_id,content_hash",
+		"facet":  facets(query.FacetLimits),
 	}
 
-	if query.Filter != nil {
-		data["filter"] = query.Filter
+	filter := append([]string(nil), query.Filter...)
+	if query.Dedupe {
+		// nullPolicy=expand keeps documents indexed before content_hash
+		filter = append(filter, "{!collapse field=content_hash nullPolicy=expand}")
+	}
+	if len(filter) > 0 {
+		data["filter"] = filter
 	}
 
-	queryData, _ := json.Marshal(data)
+	if query.WithContent {
+		data["fields"] = "id,file_id,repo,lang,path,branch,owner_id,content_hash,content"
+		q.Set("hl", "off")
+	}
+	if query.NoHighlight {
+		q.Set("hl", "off")
+	}
+	u.RawQuery = q.Encode()
 
-	println("search.go - solr_query:", utils.ByteToString(queryData))
-	println("search.go - raw_query:", u.RawQuery)
+	if query.Limit > 0 {
+		data["limit"] = query.Limit
+	}
+
+	// Terms without a field prefix are spread over the query fields
+	params := entity.Map{
+		"defType": "edismax",
+		"qf":      queryFields(),
+		"pf":      phraseFields,
+	}
+	data["params"] = params
+
+	if query.Dedupe {
+		params["expand"] = "true"
+		params["expand.rows"] = DedupeLocations
+	}
+
+	data["sort"] = sortOrder(query.Sort)
+
+	if query.Cursor != "" {
+		params["cursorMark"] = query.Cursor
+	} else {
+		// Solr rejects timeAllowed together with cursorMark
+		params["timeAllowed"] = timeAllowed()
+		if query.Offset > 0 {
+			data["offset"] = query.Offset
+		}
+	}
+
+	queryData, _ := json.Marshal(data)
 
 	payload := bytes.NewReader(queryData)
 
-	req, _ := http.NewRequest("POST", u.String(), payload)
+	req, _ := http.NewRequestWithContext(ctx, "POST", u.String(), payload)
 
 	req.Header.Add("Content-Type", "application/json")
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := solrClient.Do(req)
 	if err != nil {
-		println("ERROR", err.Error())
 		return nil, err
 	}
 
 	defer res.Body.Close()
-	body, _ := io.ReadAll(res.Body)
+	if err := checkResponse(res); err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
 
-	// Debug: Print the query information
-	fmt.Println("\n==== SOLR QUERY INFO ====")
-	fmt.Println("Query:", solrQuery)
-	fmt.Println("Highlight Query:", q.Get("hl.q"))
-	fmt.Println("==== END QUERY INFO ====")
+	if cacheable && !isPartial(body) {
+		searchCache.AddAt(generation, cacheKey, body)
+	}
 
 	return body, nil
 }
+
+// buildQuery derives the main query and the highlight query from query.
+// Query is user text and is escaped; Expression is used as it is.
+func buildQuery(query SolrQuery) (solrQuery string, hlQuery string) {
+	// Check if the query contains special characters that might need phrase searching
+	hasSpecialChars := false
+	specialChars := []string{":", ";", "{", "}", "(", ")", "[", "]", "<", ">", "=", "+", "-", "!", "*", "?", "~", "^", "&", "|", "%"}
+	for _, char := range specialChars {
+		if strings.Contains(query.Query, char) {
+			hasSpecialChars = true
+			break
+		}
+	}
+
+	// Construct the query based on content
+	if query.Expression != "" {
+		solrQuery = query.Expression
+		hlQuery = query.HighlightQuery
+		if hlQuery == "" {
+			hlQuery = solrQuery
+		}
+	} else if hasSpecialChars {
+		// For code patterns with special characters, prefer the exact phrase
+		// but also match its parts, which improves recall
+		clauses := []lucene.Query{lucene.Boost(lucene.Phrase("", query.Query), 10)}
+
+		// Add individual term matches with lower boost
+		for _, term := range strings.Fields(query.Query) {
+			if len(term) > 1 { // Only add meaningful terms
+				clauses = append(clauses, lucene.Boost(lucene.Term("", term), 2))
+			}
+		}
+
+		// Also search for the pattern without spaces
+		noSpaceQuery := strings.Join(strings.Fields(query.Query), "")
+		if noSpaceQuery != query.Query {
+			clauses = append(clauses, lucene.Boost(lucene.Term("", noSpaceQuery), 5))
+		}
+
+		solrQuery = lucene.Build(lucene.Or(clauses...))
+
+		// For highlighting, use the original query as a phrase
+		hlQuery = lucene.Phrase("content", query.Query).String()
+	} else {
+		// Use standard query for simple terms. Terms are also highlighted
+		// inside longer tokens, where identifier_ngram finds them.
+		var terms, hlTerms []lucene.Query
+		for _, term := range strings.Fields(query.Query) {
+			terms = append(terms, lucene.Term("", term))
+			hlTerms = append(hlTerms, lucene.Or(lucene.Term("content", term), lucene.Contains("content", term)))
+		}
+		solrQuery = lucene.Build(lucene.Join(terms...))
+		hlQuery = lucene.Build(lucene.Join(hlTerms...))
+	}
+
+	return solrQuery, hlQuery
+}
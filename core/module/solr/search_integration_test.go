@@ -1,6 +1,7 @@
 package solr
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"strings"
@@ -50,7 +51,7 @@ func TestSearchIntegration(t *testing.T) {
 			t.Logf("Testing query: '%s' - %s", tc.query, tc.description)
 
 			// Execute search
-			result, err := Search(query)
+			result, err := Search(context.Background(), query)
 			if err != nil {
 				t.Fatalf("Search failed for query '%s': %v", tc.query, err)
 			}
@@ -157,7 +158,7 @@ func isSolrAvailable() bool {
 	}
 
 	// Try to execute search
-	_, err := Search(query)
+	_, err := Search(context.Background(), query)
 	return err == nil
 }
 
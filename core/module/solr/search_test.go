@@ -0,0 +1,288 @@
+package solr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ahmadrosid/heline/core/entity"
+)
+
+// TestQueryFields tests building the edismax qf parameter
+func TestQueryFields(t *testing.T) {
+	testCases := []struct {
+		name     string
+		env      string
+		expected string
+	}{
+		{
+			name:     "Default boosts",
+			expected: "code_content^5 content^10 identifier_ngram^1",
+		},
+		{
+			name:     "Environment replaces defaults",
+			env:      "content^3 identifier_ngram",
+			expected: "content^3 identifier_ngram^1",
+		},
+		{
+			name:     "Zero boost leaves a field out",
+			env:      "content^10 code_content^0 identifier_ngram^2.5",
+			expected: "content^10 identifier_ngram^2.5",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.env != "" {
+				os.Setenv("SOLR_QUERY_FIELDS", tc.env)
+				defer os.Unsetenv("SOLR_QUERY_FIELDS")
+			}
+
+			if got := queryFields(); got != tc.expected {
+				t.Errorf("Expected qf %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestSortOrder tests mapping sort names to Solr sort clauses
+func TestSortOrder(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sort     string
+		expected string
+	}{
+		{name: "Empty is relevance", sort: "", expected: "score desc,id asc"},
+		{name: "Path", sort: "path", expected: "path asc,id asc"},
+		{name: "Repo", sort: "repo", expected: "repo asc,id asc"},
+		{name: "Indexed at", sort: "indexed_at", expected: "indexed_at desc,id asc"},
+		{name: "Unknown is relevance", sort: "size", expected: "score desc,id asc"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := sortOrder(tc.sort); got != tc.expected {
+				t.Errorf("Expected sort %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestFacets tests facet limits and tag exclusion in the facet request
+func TestFacets(t *testing.T) {
+	facet := facets(map[string]int{"lang": 25, "repo": 0})
+
+	testCases := []struct {
+		field string
+		limit int
+	}{
+		{field: "lang", limit: 25},
+		{field: "path", limit: DefaultFacetLimits["path"]},
+		{field: "repo", limit: DefaultFacetLimits["repo"]},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.field, func(t *testing.T) {
+			f, ok := facet[tc.field].(entity.Map)
+			if !ok {
+				t.Fatalf("Expected a facet for %s", tc.field)
+			}
+			if f["limit"] != tc.limit {
+				t.Errorf("Expected limit %d, got %v", tc.limit, f["limit"])
+			}
+			domain, _ := f["domain"].(entity.Map)
+			if domain["excludeTags"] != tc.field {
+				t.Errorf("Expected facet to exclude tag %s, got %v", tc.field, domain["excludeTags"])
+			}
+		})
+	}
+}
+
+// TestSearchTimeAllowed tests that timeAllowed is sent except with cursorMark,
+// which Solr rejects it with, and that a cancelled context stops the search
+func TestSearchTimeAllowed(t *testing.T) {
+	var params map[string]interface{}
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Params map[string]interface{} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		params = body.Params
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"responseHeader":{"status":0,"partialResults":true},"response":{"numFound":0,"docs":[]}}`)
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+
+	if _, err := Search(context.Background(), SolrQuery{Query: "foo"}); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if params["timeAllowed"] != float64(defaultTimeAllowed) {
+		t.Errorf("Expected timeAllowed %d, got %v", defaultTimeAllowed, params["timeAllowed"])
+	}
+
+	if _, err := Search(context.Background(), SolrQuery{Query: "foo", Cursor: "*"}); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if _, ok := params["timeAllowed"]; ok {
+		t.Errorf("Expected no timeAllowed with a cursor, got %v", params["timeAllowed"])
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Search(ctx, SolrQuery{Query: "foo"}); err == nil {
+		t.Errorf("Expected an error searching with a cancelled context")
+	}
+}
+
+// TestSearchCache tests that repeated searches are served from the cache
+// until it is invalidated, and that cursor pages are not cached
+func TestSearchCache(t *testing.T) {
+	requests := 0
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"responseHeader":{"status":0},"response":{"numFound":0,"docs":[]}}`)
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+
+	searches := []SolrQuery{
+		{Query: "handle search", Filter: []string{"lang:(go)", "repo:(*heline*)"}},
+		{Query: " handle  search ", Filter: []string{"repo:(*heline*)", "lang:(go)"}},
+	}
+	for _, query := range searches {
+		if _, err := Search(context.Background(), query); err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected equivalent searches to reach Solr once, got %d requests", requests)
+	}
+
+	InvalidateSearchCache()
+	if _, err := Search(context.Background(), searches[0]); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected a search after invalidation to reach Solr, got %d requests", requests)
+	}
+
+	cursor := SolrQuery{Query: "handle search", Cursor: "*"}
+	for i := 0; i < 2; i++ {
+		if _, err := Search(context.Background(), cursor); err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+	}
+	if requests != 4 {
+		t.Errorf("Expected cursor pages not to be cached, got %d requests", requests)
+	}
+}
+
+// TestSearchCacheInvalidatedDuringSearch tests that a response Solr sent
+// before an invalidation is not cached after it
+func TestSearchCacheInvalidatedDuringSearch(t *testing.T) {
+	requests := 0
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		InvalidateSearchCache()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"responseHeader":{"status":0},"response":{"numFound":0,"docs":[]}}`)
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+
+	query := SolrQuery{Query: "invalidated during search"}
+	for i := 0; i < 2; i++ {
+		if _, err := Search(context.Background(), query); err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("Expected the stale response not to be cached, got %d requests", requests)
+	}
+}
+
+// TestSearchTruncatedResponse tests that a response body cut short is an
+// error and is not cached
+func TestSearchTruncatedResponse(t *testing.T) {
+	requests := 0
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", "1000")
+		fmt.Fprint(w, `{"responseHeader":{"status":0},"response":`)
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+
+	query := SolrQuery{Query: "truncated response"}
+	for i := 0; i < 2; i++ {
+		if _, err := Search(context.Background(), query); err == nil {
+			t.Errorf("Expected an error reading a truncated response")
+		}
+	}
+	if requests != 2 {
+		t.Errorf("Expected the truncated response not to be cached, got %d requests", requests)
+	}
+}
+
+// TestBuildQuery tests that user text is escaped in the built queries
+func TestBuildQuery(t *testing.T) {
+	testCases := []struct {
+		name      string
+		query     SolrQuery
+		expected  string
+		highlight string
+	}{
+		{
+			name:      "Simple terms",
+			query:     SolrQuery{Query: "handle search"},
+			expected:  `handle search`,
+			highlight: `(content:handle OR content:*handle*) (content:search OR content:*search*)`,
+		},
+		{
+			name:      "Operators and slashes",
+			query:     SolrQuery{Query: `AND /etc "x`},
+			expected:  `\AND \/etc \"x`,
+			highlight: `(content:\AND OR content:*\AND*) (content:\/etc OR content:*\/etc*) (content:\"x OR content:*\"x*)`,
+		},
+		{
+			name:      "Special characters",
+			query:     SolrQuery{Query: `a && "b\"`},
+			expected:  `"a && \"b\\\""^10 OR \&\&^2 OR \"b\\\"^2 OR a\&\&\"b\\\"^5`,
+			highlight: `content:"a && \"b\\\""`,
+		},
+		{
+			name:      "Expression is used as it is",
+			query:     SolrQuery{Query: "ignored", Expression: "content:a AND -lang:go", HighlightQuery: "content:a"},
+			expected:  `content:a AND -lang:go`,
+			highlight: `content:a`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, hl := buildQuery(tc.query)
+			if got != tc.expected {
+				t.Errorf("Expected query %q, got %q", tc.expected, got)
+			}
+			if hl != tc.highlight {
+				t.Errorf("Expected highlight query %q, got %q", tc.highlight, hl)
+			}
+		})
+	}
+}
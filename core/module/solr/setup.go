@@ -2,6 +2,7 @@ package solr
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -10,7 +11,10 @@ import (
 )
 
 // SetupSchema checks if the Solr schema is properly set up and creates it if not
-func SetupSchema() error {
+func SetupSchema(ctx context.Context) error {
+	ctx, cancel := withTimeout(ctx, SetupTimeout)
+	defer cancel()
+
 	// Get Solr URL from environment variables or use default
 	solrBaseURL := os.Getenv("SOLR_BASE_URL")
 	if solrBaseURL == "" {
@@ -20,12 +24,12 @@ func SetupSchema() error {
 	fmt.Println("🔍 Checking Solr schema setup...")
 
 	// First, check if cores exist and create them if they don't
-	if err := createCores(solrBaseURL); err != nil {
+	if err := createCores(ctx, solrBaseURL); err != nil {
 		return fmt.Errorf("failed to create Solr cores: %w", err)
 	}
 
 	// Then set up the schema for the heline core
-	if err := setupHelineSchema(solrBaseURL); err != nil {
+	if err := setupHelineSchema(ctx, solrBaseURL); err != nil {
 		return fmt.Errorf("failed to set up heline schema: %w", err)
 	}
 
@@ -34,9 +38,9 @@ func SetupSchema() error {
 }
 
 // createCores creates the necessary Solr cores if they don't exist
-func createCores(solrBaseURL string) error {
+func createCores(ctx context.Context, solrBaseURL string) error {
 	// Check if heline core exists
-	resp, err := http.Get(fmt.Sprintf("%s/solr/admin/cores?action=STATUS&core=heline", solrBaseURL))
+	resp, err := get(ctx, fmt.Sprintf("%s/solr/admin/cores?action=STATUS&core=heline", solrBaseURL))
 	if err != nil {
 		return err
 	}
@@ -56,7 +60,7 @@ func createCores(solrBaseURL string) error {
 	if status, ok := statusResp["status"].(map[string]interface{}); !ok || status["heline"] == nil {
 		fmt.Println("Creating heline core...")
 		createURL := fmt.Sprintf("%s/solr/admin/cores?action=CREATE&name=heline&instanceDir=heline&config=solrconfig.xml&dataDir=data", solrBaseURL)
-		if _, err := http.Get(createURL); err != nil {
+		if _, err := get(ctx, createURL); err != nil {
 			return err
 		}
 	}
@@ -65,9 +69,9 @@ func createCores(solrBaseURL string) error {
 }
 
 // setupHelineSchema sets up the schema for the heline core
-func setupHelineSchema(solrBaseURL string) error {
+func setupHelineSchema(ctx context.Context, solrBaseURL string) error {
 	// Check if the schema is already set up by checking for a field
-	resp, err := http.Get(fmt.Sprintf("%s/solr/heline/schema/fields/content", solrBaseURL))
+	resp, err := get(ctx, fmt.Sprintf("%s/solr/heline/schema/fields/content", solrBaseURL))
 	if err != nil {
 		return err
 	}
@@ -76,7 +80,7 @@ func setupHelineSchema(solrBaseURL string) error {
 	// If the field exists, we assume the schema is set up
 	if resp.StatusCode == http.StatusOK {
 		fmt.Println("Solr schema already set up.")
-		return nil
+		return upgradeHelineSchema(ctx, solrBaseURL)
 	}
 
 	fmt.Println("Setting up Solr schema...")
@@ -84,9 +88,9 @@ func setupHelineSchema(solrBaseURL string) error {
 	// Create code_syntax field type for better handling of code patterns
 	codeSyntaxFieldType := map[string]interface{}{
 		"add-field-type": map[string]interface{}{
-			"name":                    "code_syntax",
-			"class":                   "solr.TextField",
-			"positionIncrementGap":    "100",
+			"name":                      "code_syntax",
+			"class":                     "solr.TextField",
+			"positionIncrementGap":      "100",
 			"autoGeneratePhraseQueries": "true",
 			"analyzer": map[string]interface{}{
 				"charFilters": []map[string]interface{}{
@@ -102,7 +106,7 @@ func setupHelineSchema(solrBaseURL string) error {
 						"class": "solr.LowerCaseFilterFactory",
 					},
 					{
-						"class": "solr.ShingleFilterFactory",
+						"class":          "solr.ShingleFilterFactory",
 						"minShingleSize": "2",
 						"maxShingleSize": "5",
 						"outputUnigrams": "true",
@@ -133,19 +137,19 @@ func setupHelineSchema(solrBaseURL string) error {
 	// Create text_ngram field type for partial matching
 	textNgramFieldType := map[string]interface{}{
 		"add-field-type": map[string]interface{}{
-			"name":                    "text_ngram",
-			"class":                   "solr.TextField",
-			"positionIncrementGap":    "100",
+			"name":                 "text_ngram",
+			"class":                "solr.TextField",
+			"positionIncrementGap": "100",
 			"analyzer": map[string]interface{}{
 				"charFilters": []map[string]interface{}{
 					{
-						"class": "solr.PatternReplaceCharFilterFactory",
-						"pattern": "([\\p{Punct}&&[^_]])",
+						"class":       "solr.PatternReplaceCharFilterFactory",
+						"pattern":     "([\\p{Punct}&&[^_]])",
 						"replacement": " $1 ",
 					},
 				},
 				"tokenizer": map[string]interface{}{
-					"class": "solr.NGramTokenizerFactory",
+					"class":       "solr.NGramTokenizerFactory",
 					"minGramSize": "2",
 					"maxGramSize": "15",
 				},
@@ -158,8 +162,8 @@ func setupHelineSchema(solrBaseURL string) error {
 			"query": map[string]interface{}{
 				"charFilters": []map[string]interface{}{
 					{
-						"class": "solr.PatternReplaceCharFilterFactory",
-						"pattern": "([\\p{Punct}&&[^_]])",
+						"class":       "solr.PatternReplaceCharFilterFactory",
+						"pattern":     "([\\p{Punct}&&[^_]])",
 						"replacement": " $1 ",
 					},
 				},
@@ -178,9 +182,9 @@ func setupHelineSchema(solrBaseURL string) error {
 	// Create text_html field type
 	fieldTypeData := map[string]interface{}{
 		"add-field-type": map[string]interface{}{
-			"name":                    "text_html",
-			"class":                   "solr.TextField",
-			"positionIncrementGap":    "100",
+			"name":                      "text_html",
+			"class":                     "solr.TextField",
+			"positionIncrementGap":      "100",
 			"autoGeneratePhraseQueries": "true",
 			"analyzer": map[string]interface{}{
 				"charFilters": []map[string]interface{}{
@@ -188,8 +192,8 @@ func setupHelineSchema(solrBaseURL string) error {
 						"class": "solr.HTMLStripCharFilterFactory",
 					},
 					{
-						"class": "solr.PatternReplaceCharFilterFactory",
-						"pattern": "([\\p{Punct}&&[^_]])",
+						"class":       "solr.PatternReplaceCharFilterFactory",
+						"pattern":     "([\\p{Punct}&&[^_]])",
 						"replacement": " $1 ",
 					},
 				},
@@ -199,14 +203,14 @@ func setupHelineSchema(solrBaseURL string) error {
 				},
 				"filters": []map[string]interface{}{
 					{
-						"class": "solr.WordDelimiterFilterFactory",
-						"generateWordParts": "1",
+						"class":               "solr.WordDelimiterFilterFactory",
+						"generateWordParts":   "1",
 						"generateNumberParts": "1",
-						"catenateWords": "1",
-						"catenateNumbers": "1",
-						"catenateAll": "0",
-						"splitOnCaseChange": "1",
-						"preserveOriginal": "1",
+						"catenateWords":       "1",
+						"catenateNumbers":     "1",
+						"catenateAll":         "0",
+						"splitOnCaseChange":   "1",
+						"preserveOriginal":    "1",
 					},
 					{
 						"class": "solr.LowerCaseFilterFactory",
@@ -215,9 +219,9 @@ func setupHelineSchema(solrBaseURL string) error {
 						"class": "solr.ASCIIFoldingFilterFactory",
 					},
 					{
-						"class": "solr.StopFilterFactory",
+						"class":      "solr.StopFilterFactory",
 						"ignoreCase": "true",
-						"words": "stopwords.txt",
+						"words":      "stopwords.txt",
 					},
 				},
 			},
@@ -227,8 +231,8 @@ func setupHelineSchema(solrBaseURL string) error {
 						"class": "solr.HTMLStripCharFilterFactory",
 					},
 					{
-						"class": "solr.PatternReplaceCharFilterFactory",
-						"pattern": "([\\p{Punct}&&[^_]])",
+						"class":       "solr.PatternReplaceCharFilterFactory",
+						"pattern":     "([\\p{Punct}&&[^_]])",
 						"replacement": " $1 ",
 					},
 				},
@@ -238,14 +242,14 @@ func setupHelineSchema(solrBaseURL string) error {
 				},
 				"filters": []map[string]interface{}{
 					{
-						"class": "solr.WordDelimiterFilterFactory",
-						"generateWordParts": "1",
+						"class":               "solr.WordDelimiterFilterFactory",
+						"generateWordParts":   "1",
 						"generateNumberParts": "1",
-						"catenateWords": "1",
-						"catenateNumbers": "1",
-						"catenateAll": "0",
-						"splitOnCaseChange": "1",
-						"preserveOriginal": "1",
+						"catenateWords":       "1",
+						"catenateNumbers":     "1",
+						"catenateAll":         "0",
+						"splitOnCaseChange":   "1",
+						"preserveOriginal":    "1",
 					},
 					{
 						"class": "solr.LowerCaseFilterFactory",
@@ -254,67 +258,27 @@ func setupHelineSchema(solrBaseURL string) error {
 						"class": "solr.ASCIIFoldingFilterFactory",
 					},
 					{
-						"class": "solr.StopFilterFactory",
+						"class":      "solr.StopFilterFactory",
 						"ignoreCase": "true",
-						"words": "stopwords.txt",
+						"words":      "stopwords.txt",
 					},
 				},
 			},
 		},
 	}
 
-	codeSyntaxFieldTypeJSON, err := json.Marshal(codeSyntaxFieldType)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/solr/heline/schema", solrBaseURL), bytes.NewBuffer(codeSyntaxFieldTypeJSON))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp1, err := client.Do(req)
-	if err != nil {
+	if err := postSchema(ctx, solrBaseURL, "schema", codeSyntaxFieldType); err != nil {
 		return err
 	}
-	defer resp1.Body.Close()
 
-	textNgramFieldTypeJSON, err := json.Marshal(textNgramFieldType)
-	if err != nil {
+	if err := postSchema(ctx, solrBaseURL, "schema", textNgramFieldType); err != nil {
 		return err
 	}
 
-	req, err = http.NewRequest("POST", fmt.Sprintf("%s/solr/heline/schema", solrBaseURL), bytes.NewBuffer(textNgramFieldTypeJSON))
-	if err != nil {
+	// Send the text_html field type
+	if err := postSchema(ctx, solrBaseURL, "schema", fieldTypeData); err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp2, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp2.Body.Close()
-
-	// Marshal and send the text_html field type
-	fieldTypeJSON, err := json.Marshal(fieldTypeData)
-	if err != nil {
-		return err
-	}
-
-	req, err = http.NewRequest("POST", fmt.Sprintf("%s/solr/heline/schema", solrBaseURL), bytes.NewBuffer(fieldTypeJSON))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp3, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp3.Body.Close()
 
 	// Create schema fields
 	fieldsData := map[string]interface{}{
@@ -363,31 +327,487 @@ func setupHelineSchema(solrBaseURL string) error {
 				"stored":      true,
 				"indexed":     true,
 			},
+			{
+				"name":    "identifier_ngram",
+				"type":    "text_ngram",
+				"stored":  true,
+				"indexed": true,
+			},
+		},
+	}
+
+	if err := postSchema(ctx, solrBaseURL, "schema", fieldsData); err != nil {
+		return err
+	}
+
+	return upgradeHelineSchema(ctx, solrBaseURL)
+}
+
+// upgradeHelineSchema applies the schema changes added after the initial
+// setup. Each step checks whether it is already applied, so it is safe to
+// run on every start.
+func upgradeHelineSchema(ctx context.Context, solrBaseURL string) error {
+	if err := setupSearchFields(ctx, solrBaseURL); err != nil {
+		return err
+	}
+
+	if err := setupCaseSensitiveField(ctx, solrBaseURL); err != nil {
+		return err
+	}
+
+	if err := setupIndexedAtField(ctx, solrBaseURL); err != nil {
+		return err
+	}
+
+	if err := setupIdentifierField(ctx, solrBaseURL); err != nil {
+		return err
+	}
+
+	if err := setupContentHashField(ctx, solrBaseURL); err != nil {
+		return err
+	}
+
+	if err := setupPathNgramField(ctx, solrBaseURL); err != nil {
+		return err
+	}
+
+	return setupSpellcheck(ctx, solrBaseURL)
+}
+
+// setupSearchFields copies content into code_content and identifier_ngram
+// on ingest so the multi-field search can use them. identifier_ngram is
+// made multi-valued to accept the content chunks, and both copies stop
+// storing a duplicate of the content. Documents indexed before this ran
+// need to be reindexed to populate the fields.
+func setupSearchFields(ctx context.Context, solrBaseURL string) error {
+	resp, err := get(ctx, fmt.Sprintf("%s/solr/heline/schema/copyfields?source.fl=content&wt=json", solrBaseURL))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var copyFields struct {
+		CopyFields []struct {
+			Source string `json:"source"`
+			Dest   string `json:"dest"`
+		} `json:"copyFields"`
+	}
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&copyFields); err != nil {
+			return err
+		}
+	}
+
+	for _, field := range copyFields.CopyFields {
+		if field.Dest == "identifier_ngram" {
+			return nil
+		}
+	}
+
+	fmt.Println("Adding copy fields for code_content and identifier_ngram...")
+
+	schemaData := map[string]interface{}{
+		"replace-field-type": map[string]interface{}{
+			"name":                 "text_ngram",
+			"class":                "solr.TextField",
+			"positionIncrementGap": "100",
+			"analyzer": map[string]interface{}{
+				"charFilters": []map[string]interface{}{
+					{
+						"class": "solr.HTMLStripCharFilterFactory",
+					},
+					{
+						"class":       "solr.PatternReplaceCharFilterFactory",
+						"pattern":     "([\\p{Punct}&&[^_]])",
+						"replacement": " ",
+					},
+				},
+				"tokenizer": map[string]interface{}{
+					"class": "solr.WhitespaceTokenizerFactory",
+				},
+				"filters": []map[string]interface{}{
+					{
+						"class": "solr.LowerCaseFilterFactory",
+					},
+					{
+						"class":       "solr.NGramFilterFactory",
+						"minGramSize": "2",
+						"maxGramSize": "15",
+					},
+				},
+			},
+			"query": map[string]interface{}{
+				"charFilters": []map[string]interface{}{
+					{
+						"class":       "solr.PatternReplaceCharFilterFactory",
+						"pattern":     "([\\p{Punct}&&[^_]])",
+						"replacement": " ",
+					},
+				},
+				"tokenizer": map[string]interface{}{
+					"class": "solr.WhitespaceTokenizerFactory",
+				},
+				"filters": []map[string]interface{}{
+					{
+						"class": "solr.LowerCaseFilterFactory",
+					},
+				},
+			},
+		},
+		"replace-field": []map[string]interface{}{
+			{
+				"name":        "code_content",
+				"type":        "code_syntax",
+				"multiValued": true,
+				"stored":      false,
+				"indexed":     true,
+			},
 			{
 				"name":        "identifier_ngram",
 				"type":        "text_ngram",
-				"stored":      true,
+				"multiValued": true,
+				"stored":      false,
 				"indexed":     true,
 			},
 		},
+		"add-copy-field": []map[string]interface{}{
+			{
+				"source": "content",
+				"dest":   "code_content",
+			},
+			{
+				"source": "content",
+				"dest":   "identifier_ngram",
+			},
+		},
 	}
 
-	fieldsJSON, err := json.Marshal(fieldsData)
+	return postSchema(ctx, solrBaseURL, "schema", schemaData)
+}
+
+// setupCaseSensitiveField adds content_cs, a copy of content split into
+// the same n-grams as identifier_ngram but keeping the original letter
+// case, so case=sensitive searches only fetch candidates that contain the
+// term with its case, including inside identifiers. Cores that have the
+// earlier whitespace-tokenized content_cs get it replaced. Documents indexed
+// before either ran need to be reindexed to be found by case-sensitive
+// searches.
+func setupCaseSensitiveField(ctx context.Context, solrBaseURL string) error {
+	resp, err := get(ctx, fmt.Sprintf("%s/solr/heline/schema/fields/content_cs", solrBaseURL))
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
-	req, err = http.NewRequest("POST", fmt.Sprintf("%s/solr/heline/schema", solrBaseURL), bytes.NewBuffer(fieldsJSON))
+	var existing struct {
+		Field struct {
+			Type string `json:"type"`
+		} `json:"field"`
+	}
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&existing); err != nil {
+			return err
+		}
+		if existing.Field.Type == "text_ngram_cs" {
+			return nil
+		}
+	}
+
+	fmt.Println("Adding case-sensitive content field...")
+
+	field := map[string]interface{}{
+		"name":        "content_cs",
+		"type":        "text_ngram_cs",
+		"multiValued": true,
+		"stored":      false,
+		"indexed":     true,
+	}
+	schemaData := map[string]interface{}{
+		"add-field-type": map[string]interface{}{
+			"name":                 "text_ngram_cs",
+			"class":                "solr.TextField",
+			"positionIncrementGap": "100",
+			"analyzer": map[string]interface{}{
+				"charFilters": []map[string]interface{}{
+					{
+						"class": "solr.HTMLStripCharFilterFactory",
+					},
+					{
+						"class":       "solr.PatternReplaceCharFilterFactory",
+						"pattern":     "([\\p{Punct}&&[^_]])",
+						"replacement": " ",
+					},
+				},
+				"tokenizer": map[string]interface{}{
+					"class": "solr.WhitespaceTokenizerFactory",
+				},
+				"filters": []map[string]interface{}{
+					{
+						"class":            "solr.NGramFilterFactory",
+						"minGramSize":      "2",
+						"maxGramSize":      "15",
+						"preserveOriginal": "true",
+					},
+				},
+			},
+			"query": map[string]interface{}{
+				"charFilters": []map[string]interface{}{
+					{
+						"class":       "solr.PatternReplaceCharFilterFactory",
+						"pattern":     "([\\p{Punct}&&[^_]])",
+						"replacement": " ",
+					},
+				},
+				"tokenizer": map[string]interface{}{
+					"class": "solr.WhitespaceTokenizerFactory",
+				},
+			},
+		},
+	}
+	if resp.StatusCode == http.StatusOK {
+		schemaData["replace-field"] = field
+	} else {
+		schemaData["add-field"] = field
+		schemaData["add-copy-field"] = map[string]interface{}{
+			"source": "content",
+			"dest":   "content_cs",
+		}
+	}
+
+	return postSchema(ctx, solrBaseURL, "schema", schemaData)
+}
+
+// setupIndexedAtField adds indexed_at, which Solr fills with the time each
+// document is written, for sort=indexed_at. Documents indexed before it
+// existed have no value and sort last.
+func setupIndexedAtField(ctx context.Context, solrBaseURL string) error {
+	resp, err := get(ctx, fmt.Sprintf("%s/solr/heline/schema/fields/indexed_at", solrBaseURL))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	fmt.Println("Adding indexed_at field...")
+
+	schemaData := map[string]interface{}{
+		"add-field": map[string]interface{}{
+			"name":      "indexed_at",
+			"type":      "pdate",
+			"default":   "NOW",
+			"stored":    true,
+			"indexed":   true,
+			"docValues": true,
+		},
+	}
+
+	return postSchema(ctx, solrBaseURL, "schema", schemaData)
+}
+
+// setupIdentifierField adds identifier, which indexes every identifier in
+// content as a whole term with its original case. Suggestions complete
+// against its terms. Documents indexed before it existed need to be
+// reindexed to contribute suggestions.
+func setupIdentifierField(ctx context.Context, solrBaseURL string) error {
+	resp, err := get(ctx, fmt.Sprintf("%s/solr/heline/schema/fields/identifier", solrBaseURL))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	fmt.Println("Adding identifier field...")
+
+	schemaData := map[string]interface{}{
+		"add-field-type": map[string]interface{}{
+			"name":                 "text_identifier",
+			"class":                "solr.TextField",
+			"positionIncrementGap": "100",
+			"analyzer": map[string]interface{}{
+				"charFilters": []map[string]interface{}{
+					{
+						"class": "solr.HTMLStripCharFilterFactory",
+					},
+				},
+				"tokenizer": map[string]interface{}{
+					"class":   "solr.PatternTokenizerFactory",
+					"pattern": "[A-Za-z_$][A-Za-z0-9_$]*",
+					"group":   "0",
+				},
+				"filters": []map[string]interface{}{
+					{
+						"class": "solr.LengthFilterFactory",
+						"min":   "3",
+						"max":   "128",
+					},
+				},
+			},
+		},
+		"add-field": map[string]interface{}{
+			"name":        "identifier",
+			"type":        "text_identifier",
+			"multiValued": true,
+			"stored":      false,
+			"indexed":     true,
+		},
+		"add-copy-field": map[string]interface{}{
+			"source": "content",
+			"dest":   "identifier",
+		},
+	}
+
+	return postSchema(ctx, solrBaseURL, "schema", schemaData)
+}
+
+// setupSpellcheck adds a spellcheck component over the indexed identifiers
+// and a request handler for it through the config API. It reads straight
+// from the index, so there is no dictionary to build.
+func setupSpellcheck(ctx context.Context, solrBaseURL string) error {
+	resp, err := get(ctx, fmt.Sprintf("%s/solr/heline/config/overlay?wt=json", solrBaseURL))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var overlay struct {
+		Overlay struct {
+			RequestHandler map[string]interface{} `json:"requestHandler"`
+		} `json:"overlay"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&overlay); err != nil {
+		return err
+	}
+
+	if _, ok := overlay.Overlay.RequestHandler[spellcheckHandler]; ok {
+		return nil
+	}
+
+	fmt.Println("Adding identifier spellcheck...")
+
+	configData := map[string]interface{}{
+		"add-searchcomponent": map[string]interface{}{
+			"name":  "identifier_spellcheck",
+			"class": "solr.SpellCheckComponent",
+			"spellchecker": map[string]interface{}{
+				"name":            "identifier",
+				"field":           "identifier",
+				"classname":       "solr.DirectSolrSpellChecker",
+				"distanceMeasure": "internal",
+				"accuracy":        0.5,
+				"maxEdits":        2,
+				"minPrefix":       1,
+				"maxInspections":  5,
+				"minQueryLength":  4,
+			},
+		},
+		"add-requesthandler": map[string]interface{}{
+			"name":  spellcheckHandler,
+			"class": "solr.SearchHandler",
+			"defaults": map[string]interface{}{
+				"rows":                       0,
+				"spellcheck":                 "on",
+				"spellcheck.dictionary":      "identifier",
+				"spellcheck.count":           5,
+				"spellcheck.collate":         "true",
+				"spellcheck.maxCollations":   3,
+				"spellcheck.onlyMorePopular": "false",
+			},
+			"last-components": []string{"identifier_spellcheck"},
+		},
+	}
+
+	return postSchema(ctx, solrBaseURL, "config", configData)
+}
+
+// setupContentHashField adds content_hash, which the indexer fills with a
+// hash of each file's content so dedupe=true can collapse identical files
+// across forks and branches. Documents indexed before it existed have no
+// hash and are never collapsed.
+func setupContentHashField(ctx context.Context, solrBaseURL string) error {
+	resp, err := get(ctx, fmt.Sprintf("%s/solr/heline/schema/fields/content_hash", solrBaseURL))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	fmt.Println("Adding content_hash field...")
+
+	schemaData := map[string]interface{}{
+		"add-field": map[string]interface{}{
+			"name":      "content_hash",
+			"type":      "string",
+			"stored":    true,
+			"indexed":   true,
+			"docValues": true,
+		},
+	}
+
+	return postSchema(ctx, solrBaseURL, "schema", schemaData)
+}
+
+// setupPathNgramField adds path_ngram, a copy of id split into the same
+// n-grams as identifier_ngram. mode=files scores its candidates on it, so
+// the paths it keeps are the ones with more of the query typed in a row,
+// and the shorter ones. Documents indexed before it existed need to be
+// reindexed to be scored.
+func setupPathNgramField(ctx context.Context, solrBaseURL string) error {
+	resp, err := get(ctx, fmt.Sprintf("%s/solr/heline/schema/fields/path_ngram", solrBaseURL))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	fmt.Println("Adding path_ngram field...")
+
+	schemaData := map[string]interface{}{
+		"add-field": map[string]interface{}{
+			"name":    "path_ngram",
+			"type":    "text_ngram",
+			"stored":  false,
+			"indexed": true,
+		},
+		"add-copy-field": map[string]interface{}{
+			"source": "id",
+			"dest":   "path_ngram",
+		},
+	}
+
+	return postSchema(ctx, solrBaseURL, "schema", schemaData)
+}
+
+// postSchema sends body as JSON to path on the heline core, such as
+// "schema" or "config", and returns Solr's error if it rejects the change.
+func postSchema(ctx context.Context, solrBaseURL, path string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/solr/heline/%s", solrBaseURL, path), bytes.NewReader(data))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp4, err := client.Do(req)
+	res, err := solrClient.Do(req)
 	if err != nil {
 		return err
 	}
-	defer resp4.Body.Close()
+	defer res.Body.Close()
 
-	return nil
+	return checkResponse(res)
 }
@@ -0,0 +1,41 @@
+package solr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestPostSchema tests that rejected schema and config changes are errors
+func TestPostSchema(t *testing.T) {
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Expected a JSON body: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/solr/heline/config" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, `{"error":{"msg":"unknown component"}}`)
+			return
+		}
+		fmt.Fprintln(w, `{"responseHeader":{"status":0}}`)
+	}))
+	defer mockSolr.Close()
+
+	body := map[string]interface{}{"add-field": map[string]interface{}{"name": "x"}}
+	if err := postSchema(context.Background(), mockSolr.URL, "schema", body); err != nil {
+		t.Errorf("Expected an accepted change to succeed, got %v", err)
+	}
+
+	err := postSchema(context.Background(), mockSolr.URL, "config", body)
+	var solrErr *Error
+	if !errors.As(err, &solrErr) || solrErr.Status != http.StatusBadRequest || solrErr.Message != "unknown component" {
+		t.Errorf("Expected Solr's error for a rejected change, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package solr
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
 	"testing"
@@ -31,7 +32,7 @@ func TestSpecialCharacterHighlighting(t *testing.T) {
 			
 			t.Logf("Testing problematic query: '%s' (%s)", test.query, test.description)
 			
-			result, err := Search(query)
+			result, err := Search(context.Background(), query)
 			if err != nil {
 				t.Fatalf("Search failed for query '%s': %v", test.query, err)
 			}
@@ -0,0 +1,48 @@
+package solr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+)
+
+// spellcheckHandler serves spelling suggestions built from the identifier
+// field. It is added by SetupSchema.
+const spellcheckHandler = "/didyoumean"
+
+// Spellcheck asks Solr for corrections of the identifiers in text. The
+// response holds up to three corrected versions of text under
+// spellcheck.collations.
+func Spellcheck(ctx context.Context, text string) ([]byte, error) {
+	ctx, cancel := withTimeout(ctx, RequestTimeout)
+	defer cancel()
+
+	// Get Solr URL from environment variables or use default
+	solrBaseURL := os.Getenv("SOLR_BASE_URL")
+	if solrBaseURL == "" {
+		solrBaseURL = "http://localhost:8984"
+	}
+
+	u, err := url.Parse(fmt.Sprintf("%s/solr/heline%s", solrBaseURL, spellcheckHandler))
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("q", "*:*")
+	q.Set("spellcheck.q", text)
+	q.Set("wt", "json")
+	u.RawQuery = q.Encode()
+
+	res, err := get(ctx, u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if err := checkResponse(res); err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(res.Body)
+}
@@ -0,0 +1,96 @@
+package solr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ahmadrosid/heline/core/entity"
+)
+
+// SuggestFields are the fields suggestions are drawn from.
+var SuggestFields = []string{"identifier", "repo", "path"}
+
+// Suggest returns up to limit terms per field of SuggestFields that
+// complete prefix, with their document frequencies, from Solr's terms
+// handler. Identifiers must start with prefix, keeping their case, while
+// repos and paths match case-insensitively at the start of any segment so
+// "hel" completes ahmadrosid/heline.
+func Suggest(ctx context.Context, prefix string, limit int) ([]byte, error) {
+	ctx, cancel := withTimeout(ctx, RequestTimeout)
+	defer cancel()
+
+	// Get Solr URL from environment variables or use default
+	solrBaseURL := os.Getenv("SOLR_BASE_URL")
+	if solrBaseURL == "" {
+		solrBaseURL = "http://localhost:8984"
+	}
+
+	// The terms handler only reads terms.prefix and terms.regex as global
+	// params, so each field needs a request of its own.
+	result := entity.SolrTermsResult{Terms: map[string][]interface{}{}}
+	for _, field := range SuggestFields {
+		terms, err := suggestTerms(ctx, solrBaseURL, field, prefix, limit)
+		if err != nil {
+			return nil, err
+		}
+		result.Terms[field] = terms
+	}
+
+	return json.Marshal(result)
+}
+
+// suggestTerms returns the flattened terms and counts of field that
+// complete prefix.
+func suggestTerms(ctx context.Context, solrBaseURL, field, prefix string, limit int) ([]interface{}, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/solr/heline/terms", solrBaseURL))
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("terms", "true")
+	q.Set("terms.fl", field)
+	q.Set("terms.limit", strconv.Itoa(limit))
+	q.Set("terms.sort", "count")
+	if field == "identifier" {
+		q.Set("terms.prefix", prefix)
+	} else {
+		q.Set("terms.regex", "(.*/)?"+quoteRegex(prefix)+".*")
+		q.Set("terms.regex.flag", "case_insensitive")
+	}
+	q.Set("wt", "json")
+	u.RawQuery = q.Encode()
+
+	res, err := get(ctx, u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if err := checkResponse(res); err != nil {
+		return nil, err
+	}
+
+	var data entity.SolrTermsResult
+	if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	return data.Terms[field], nil
+}
+
+// quoteRegex escapes s for the Java regular expressions used by
+// terms.regex. Backslash is allowed before any non-alphanumeric character.
+func quoteRegex(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -15,6 +15,7 @@ type StreamEncoder struct {
 	w             io.Writer
 	first         bool
 	array         bool
+	lines         bool
 	quotedColumns map[string][]byte
 	marshalFn     Marshaler
 }
@@ -30,9 +31,22 @@ func NewGenericStreamEncoder(w io.Writer, marshalFn Marshaler, array bool) *Stre
 	}
 }
 
+// NewLineStreamEncoder returns an encoder for newline-delimited JSON.
+// Every row is written to w as soon as it is encoded, so rows can be
+// streamed to a client without buffering the whole result.
+func NewLineStreamEncoder(w io.Writer, marshalFn Marshaler) *StreamEncoder {
+	sw := NewGenericStreamEncoder(w, marshalFn, false)
+	sw.lines = true
+	return sw
+}
+
 var commaNl = []byte(",\n")
 
 func (sw *StreamEncoder) EncodeRow(row interface{}) error {
+	if sw.lines {
+		return sw.encodeLine(row)
+	}
+
 	if !sw.first {
 		_, err := sw.buf.Write(commaNl)
 		if err != nil {
@@ -102,7 +116,35 @@ func (sw *StreamEncoder) EncodeRow(row interface{}) error {
 	return sw.buf.WriteByte('}')
 }
 
+func (sw *StreamEncoder) encodeLine(row interface{}) error {
+	sw.first = false
+
+	bs, err := sw.marshalFn(row)
+	if err != nil {
+		return err
+	}
+
+	sw.buf.Write(bs)
+	sw.buf.WriteByte('\n')
+	return sw.Flush()
+}
+
+// Flush writes everything encoded so far to the underlying writer.
+func (sw *StreamEncoder) Flush() error {
+	for sw.buf.Len() > 0 {
+		_, err := sw.buf.WriteTo(sw.w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (sw *StreamEncoder) Close() error {
+	if sw.lines {
+		return sw.Flush()
+	}
+
 	// Handle case of EncodeRow never called
 	if sw.first {
 		err := sw.buf.WriteByte('[')
@@ -168,3 +210,7 @@ func Encode(out io.Writer, obj interface{}) error {
 func NewStreamEncoder(out io.Writer, array bool) *StreamEncoder {
 	return NewGenericStreamEncoder(out, json.Marshal, array)
 }
+
+func NewNDJSONStreamEncoder(out io.Writer) *StreamEncoder {
+	return NewLineStreamEncoder(out, json.Marshal)
+}
@@ -172,6 +172,7 @@ impl Indexer {
     async fn process_file(&self, meta: MetaIndexFile) {
         match parser::read_file(&meta.path) {
             Ok((input, lang)) => {
+                let content_hash = utils::content_hash(&input);
                 let html = parser::render_html(input, lang);
                 let paths = meta.path.to_str().unwrap().split("/").collect::<Vec<_>>();
                 let file_path = paths[meta.root_path_len..paths.len()].to_vec().join("/");
@@ -199,6 +200,7 @@ impl Indexer {
                     repo: meta.git_repo.to_string(),
                     branch: meta.branch.to_owned(),
                     lang: lang.to_string(),
+                    content_hash,
                     content: Vec::new(),
                 };
                 self.store(data, &html, &meta.base_url).await;
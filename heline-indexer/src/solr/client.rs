@@ -9,6 +9,7 @@ pub struct GitFile {
     pub repo: String,
     pub branch: String,
     pub lang: String,
+    pub content_hash: String,
     pub content: Vec<String>,
 }
 
@@ -85,3 +85,17 @@ pub fn get_git_ssh_url(git_url: &str) -> String {
     let repo_path = get_git_repo_path(git_url);
     format!("https://{}/{}.git", git_host, repo_path)
 }
+
+// Hashes file content with 64-bit FNV-1a, so identical files in different
+// forks and branches share a hash that stays stable across builds.
+pub fn content_hash(input: &[char]) -> String {
+    let mut hash: u64 = 0xcbf29ce484222325;
+    let mut buf = [0u8; 4];
+    for c in input {
+        for byte in c.encode_utf8(&mut buf).as_bytes() {
+            hash ^= *byte as u64;
+            hash = hash.wrapping_mul(0x100000001b3);
+        }
+    }
+    format!("{:016x}", hash)
+}
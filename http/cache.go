@@ -0,0 +1,83 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ahmadrosid/heline/core/module/solr"
+)
+
+// lastCompletedJob is the latest completion time among the indexing jobs
+// seen so far. It only ever holds times reported by the indexer, so clock
+// skew between the two services does not matter.
+var (
+	lastCompletedJobMu sync.Mutex
+	lastCompletedJob   time.Time
+)
+
+// noteCompletedJobs drops the cached searches when any of jobs finished
+// after the last completed job seen, since the index has changed.
+func noteCompletedJobs(jobs ...JobStatus) {
+	lastCompletedJobMu.Lock()
+	defer lastCompletedJobMu.Unlock()
+
+	changed := false
+	for _, job := range jobs {
+		if job.CompletedAt != nil && job.CompletedAt.After(lastCompletedJob) {
+			lastCompletedJob = *job.CompletedAt
+			changed = true
+		}
+	}
+
+	if changed {
+		solr.InvalidateSearchCache()
+	}
+}
+
+// defaultIndexWatchInterval is how often WatchIndexJobs polls the indexer.
+// It can be set with the INDEX_WATCH_INTERVAL environment variable.
+const defaultIndexWatchInterval = 15 * time.Second
+
+func indexWatchInterval() time.Duration {
+	if env := os.Getenv("INDEX_WATCH_INTERVAL"); env != "" {
+		if interval, err := time.ParseDuration(env); err == nil && interval > 0 {
+			return interval
+		}
+	}
+	return defaultIndexWatchInterval
+}
+
+// WatchIndexJobs polls the indexer's job list until ctx is done and drops
+// the cached searches whenever a job completes. The indexer writes to Solr
+// directly, so this is how the API learns that the index has changed. A
+// failed poll is retried at the next tick.
+func WatchIndexJobs(ctx context.Context) {
+	client := NewIndexerClient()
+	ticker := time.NewTicker(indexWatchInterval())
+	defer ticker.Stop()
+
+	for {
+		if jobs, err := client.ListJobs(); err == nil {
+			noteCompletedJobs(jobs...)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// handleCacheStats reports the search cache hits, misses and size.
+func handleCacheStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, solr.SearchCacheStats())
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ahmadrosid/heline/core/module/solr"
+)
+
+// TestWatchIndexJobs tests that a completed indexing job drops the cached
+// searches without anyone polling the jobs API
+func TestWatchIndexJobs(t *testing.T) {
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"response":{"numFound":0,"docs":[]}}`)
+	}))
+	defer mockSolr.Close()
+
+	completedAt := time.Now().UTC().Add(time.Hour).Format(time.RFC3339Nano)
+	mockIndexer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jobs" {
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `[{"git_url":"https://github.com/a/b","status":"completed","created_at":%q,"completed_at":%q}]`, completedAt, completedAt)
+	}))
+	defer mockIndexer.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+	os.Setenv("INDEXER_URL", mockIndexer.URL)
+	defer os.Unsetenv("INDEXER_URL")
+
+	if _, err := solr.Search(context.Background(), solr.SolrQuery{Query: "watch"}); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if solr.SearchCacheStats().Entries == 0 {
+		t.Fatalf("Expected the search to be cached")
+	}
+
+	// A done context stops the watcher after its first poll
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	WatchIndexJobs(ctx)
+
+	if entries := solr.SearchCacheStats().Entries; entries != 0 {
+		t.Errorf("Expected the cache to be dropped, got %d entries", entries)
+	}
+}
@@ -1,12 +1,8 @@
 package http
 
 import (
-	"encoding/json"
-	"fmt"
 	"net/http"
 	"strings"
-
-	"github.com/ahmadrosid/heline/core/entity"
 )
 
 var allowedMethods = []string{
@@ -27,22 +23,6 @@ type CorsConfig struct {
 	allowedOrigin string
 }
 
-func respondError(w http.ResponseWriter, status int, err ...error) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-
-	errors := []string{}
-	for _, e := range err {
-		errors = append(errors, e.Error())
-	}
-
-	enc := json.NewEncoder(w)
-	enc.Encode(entity.Map{
-		"status": status,
-		"errors": errors,
-	})
-}
-
 func StrListContains(sources []string, target string) bool {
 	for _, item := range sources {
 		if item == target {
@@ -63,16 +43,17 @@ func wrapCORSHandler(h http.Handler, config *CorsConfig) http.Handler {
 		}
 
 		if !isValidOrigin(config, origin) {
-			respondError(w, http.StatusForbidden, fmt.Errorf("origin not allowed"))
+			writeError(w, http.StatusForbidden, "Origin not allowed")
 			return
 		}
 
 		if req.Method == http.MethodOptions && !StrListContains(allowedMethods, requestMethod) {
-			w.WriteHeader(http.StatusMethodNotAllowed)
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 			return
 		}
 
 		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Expose-Headers", requestIDHeader)
 		w.Header().Set("Vary", "Origin")
 
 		if req.Method == http.MethodOptions {
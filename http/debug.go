@@ -0,0 +1,51 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ahmadrosid/heline/core/entity"
+)
+
+// isAdmin reports whether r carries the ADMIN_TOKEN environment variable
+// as a bearer token. Nobody is an admin while ADMIN_TOKEN is unset.
+func isAdmin(r *http.Request) bool {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		return false
+	}
+
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(auth[len(prefix):]), []byte(token)) == 1
+}
+
+// searchDebug builds the debug section of a search response from the
+// debug output of Solr, for a request to Solr sent at started.
+func searchDebug(data entity.SolrResult, started time.Time) *entity.SearchDebug {
+	debug := &entity.SearchDebug{
+		Timing: entity.Map{
+			"qtime_ms":   data.Header.QTime,
+			"request_ms": time.Since(started).Milliseconds(),
+		},
+	}
+	if hlQuery, ok := data.Header.Params["hl.q"].(string); ok {
+		debug.HighlightQuery = hlQuery
+	}
+
+	if data.Debug != nil {
+		debug.Query = data.Debug.QueryString
+		debug.ParsedQuery = data.Debug.ParsedQuery
+		debug.Filters = data.Debug.FilterQueries
+		debug.ParsedFilters = data.Debug.ParsedFilterQueries
+		debug.Explain = data.Debug.Explain
+		debug.Timing["solr"] = data.Debug.Timing
+	}
+	return debug
+}
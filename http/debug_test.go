@@ -0,0 +1,102 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ahmadrosid/heline/core/entity"
+)
+
+// TestSearchDebug tests debug=true for admins and everyone else
+func TestSearchDebug(t *testing.T) {
+	calls := 0
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Query().Get("debugQuery") != "true" {
+			t.Errorf("Expected debugQuery=true")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{
+			"responseHeader":{"status":0,"QTime":7,"params":{"hl.q":"content:debugme"}},
+			"response":{"numFound":1,"docs":[{"id":"a/heline/main.go"}]},
+			"highlighting":{"a/heline/main.go":{"content":["<mark>debugme</mark>"]}},
+			"debug":{
+				"querystring":"debugme",
+				"parsedquery":"+DisjunctionMaxQuery((content:debugme)^10.0)",
+				"filter_queries":["{!tag=lang}lang:(go)"],
+				"parsed_filter_queries":["lang:go"],
+				"explain":{"a/heline/main.go":"\n1.5 = weight(content:debugme)"},
+				"timing":{"time":3.0}
+			}
+		}`)
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+	os.Setenv("ADMIN_TOKEN", "secret")
+	defer os.Unsetenv("ADMIN_TOKEN")
+
+	testCases := []struct {
+		name           string
+		token          string
+		accept         string
+		expectedStatus int
+	}{
+		{"Without a token", "", "", http.StatusForbidden},
+		{"With a wrong token", "guess", "", http.StatusForbidden},
+		{"As an admin", "secret", "", http.StatusOK},
+		{"As an admin again", "secret", "", http.StatusOK},
+		{"As an admin over NDJSON", "secret", ndjsonContentType, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/search?q=debugme&filter[lang]=go&debug=true", nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			rr := httptest.NewRecorder()
+			handleSearch(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var result entity.CodeSearchResult
+			if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			debug := result.Debug
+			if debug == nil {
+				t.Fatalf("Expected a debug section")
+			}
+			if debug.Query != "debugme" || debug.HighlightQuery != "content:debugme" {
+				t.Errorf("Unexpected queries %q and %q", debug.Query, debug.HighlightQuery)
+			}
+			if len(debug.Filters) != 1 || debug.Explain["a/heline/main.go"] == "" {
+				t.Errorf("Expected the filters and explanations, got %+v", debug)
+			}
+			if debug.Timing["qtime_ms"] != float64(7) || debug.Timing["solr"] == nil {
+				t.Errorf("Expected the timing breakdown, got %v", debug.Timing)
+			}
+		})
+	}
+
+	// Debug responses are not cached, so every admin request reaches Solr
+	if calls != 2 {
+		t.Errorf("Expected 2 requests to Solr, got %d", calls)
+	}
+}
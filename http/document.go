@@ -0,0 +1,83 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/ahmadrosid/heline/core/entity"
+	"github.com/ahmadrosid/heline/core/module/solr"
+)
+
+// handleGetDocument returns one indexed file with all of its content chunks
+// reassembled in the order the indexer stored them.
+func handleGetDocument(w http.ResponseWriter, r *http.Request, id string) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "Document ID is required")
+		return
+	}
+
+	result, err := solr.GetDocument(r.Context(), id)
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+
+	var data entity.SolrGetResult
+	if err := json.Unmarshal(result, &data); err != nil {
+		writeInvalidResponse(w, err)
+		return
+	}
+
+	if data.Doc == nil {
+		writeError(w, http.StatusNotFound, "Document not found")
+		return
+	}
+
+	// Each chunk is a run of highlight-table rows terminated by a newline,
+	// so concatenating them restores the original file.
+	enc.Encode(entity.CodeDocument{
+		ID:      data.Doc.ID,
+		FileID:  data.Doc.FileID,
+		OwnerID: data.Doc.OwnerID,
+		Repo:    data.Doc.Repo,
+		Branch:  data.Doc.Branch,
+		Path:    data.Doc.Path,
+		Lang:    data.Doc.Lang,
+		Content: strings.Join(data.Doc.Content, ""),
+		Chunks:  len(data.Doc.Content),
+	})
+}
+
+// fetchDocuments returns the reassembled markup of each indexed file in
+// ids, keyed by id.
+func fetchDocuments(ctx context.Context, ids []string) (map[string]string, error) {
+	documents := map[string]string{}
+	if len(ids) == 0 {
+		return documents, nil
+	}
+
+	result, err := solr.GetDocuments(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var data entity.SolrGetResult
+	if err := json.Unmarshal(result, &data); err != nil {
+		return nil, err
+	}
+
+	docs := data.Response.Docs
+	if data.Doc != nil {
+		docs = append(docs, *data.Doc)
+	}
+
+	for _, doc := range docs {
+		documents[doc.ID] = strings.Join(doc.Content, "")
+	}
+	return documents, nil
+}
@@ -0,0 +1,168 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/ahmadrosid/heline/core/entity"
+	"github.com/ahmadrosid/heline/core/module/solr"
+)
+
+// requestIDHeader carries the id of a request, taken from the client when
+// it sends one and generated otherwise.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen caps the length of request ids accepted from clients.
+const maxRequestIDLen = 64
+
+// Error codes of entity.APIError.
+const (
+	codeBadRequest       = "bad_request"
+	codeInvalidQuery     = "invalid_query"
+	codeNotFound         = "not_found"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeNotAcceptable    = "not_acceptable"
+	codeForbidden        = "forbidden"
+	codeTimeout          = "timeout"
+	codeUnavailable      = "unavailable"
+	codeSolrError        = "solr_error"
+	codeIndexerError     = "indexer_error"
+	codeInternal         = "internal_error"
+)
+
+// statusCodes are the codes used for errors that have no more specific one.
+var statusCodes = map[int]string{
+	http.StatusBadRequest:          codeBadRequest,
+	http.StatusForbidden:           codeForbidden,
+	http.StatusNotFound:            codeNotFound,
+	http.StatusMethodNotAllowed:    codeMethodNotAllowed,
+	http.StatusNotAcceptable:       codeNotAcceptable,
+	http.StatusServiceUnavailable:  codeUnavailable,
+	http.StatusGatewayTimeout:      codeTimeout,
+	http.StatusInternalServerError: codeInternal,
+}
+
+// withRequestID gives every request an id, which is returned in the
+// X-Request-ID header and in error responses.
+func withRequestID(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" || len(id) > maxRequestIDLen {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		h.ServeHTTP(w, r)
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestID returns the id of the request w responds to, setting one when
+// the handler was called without withRequestID.
+func requestID(w http.ResponseWriter) string {
+	id := w.Header().Get(requestIDHeader)
+	if id == "" {
+		id = newRequestID()
+		w.Header().Set(requestIDHeader, id)
+	}
+	return id
+}
+
+// writeError writes an error response with the default code for status.
+func writeError(w http.ResponseWriter, status int, message string) {
+	code, ok := statusCodes[status]
+	if !ok {
+		code = codeInternal
+	}
+	writeAPIError(w, status, entity.APIError{
+		Code:    code,
+		Message: message,
+	})
+}
+
+func writeAPIError(w http.ResponseWriter, status int, apiErr entity.APIError) {
+	apiErr.RequestID = requestID(w)
+	writeJSON(w, status, entity.ErrorResponse{
+		Error: apiErr,
+	})
+}
+
+// writeFailure writes the error response for err, returned by a call to
+// Solr or the indexer.
+func writeFailure(w http.ResponseWriter, err error) {
+	status, apiErr := failure(err)
+	writeAPIError(w, status, apiErr)
+}
+
+// failure maps err to a status and error. Queries Solr rejects as invalid
+// are the client's fault; other Solr errors and unreachable backends are
+// reported as a bad gateway, and timeouts as a gateway timeout.
+func failure(err error) (int, entity.APIError) {
+	var solrErr *solr.Error
+	var indexerErr *IndexerError
+	var urlErr *url.Error
+
+	switch {
+	case errors.As(err, &solrErr):
+		status, code := http.StatusBadGateway, codeSolrError
+		if solrErr.Status == http.StatusBadRequest {
+			status, code = http.StatusBadRequest, codeInvalidQuery
+		}
+		return status, entity.APIError{
+			Code:    code,
+			Message: solrErr.Message,
+			Details: entity.Map{
+				"solr_status": solrErr.Status,
+			},
+		}
+
+	case errors.As(err, &indexerErr):
+		status, code := http.StatusBadGateway, codeIndexerError
+		if indexerErr.Status == http.StatusNotFound {
+			status, code = http.StatusNotFound, codeNotFound
+		}
+		return status, entity.APIError{
+			Code:    code,
+			Message: err.Error(),
+			Details: entity.Map{
+				"indexer_status": indexerErr.Status,
+			},
+		}
+
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, entity.APIError{
+			Code:    codeTimeout,
+			Message: err.Error(),
+		}
+
+	case errors.As(err, &urlErr):
+		return http.StatusBadGateway, entity.APIError{
+			Code:    codeUnavailable,
+			Message: err.Error(),
+		}
+
+	default:
+		return http.StatusInternalServerError, entity.APIError{
+			Code:    codeInternal,
+			Message: err.Error(),
+		}
+	}
+}
+
+// writeInvalidResponse writes the error response for a Solr response that
+// could not be decoded.
+func writeInvalidResponse(w http.ResponseWriter, err error) {
+	writeAPIError(w, http.StatusBadGateway, entity.APIError{
+		Code:    codeSolrError,
+		Message: fmt.Sprintf("Invalid response from Solr: %s", err.Error()),
+	})
+}
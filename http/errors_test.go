@@ -0,0 +1,140 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/ahmadrosid/heline/core/entity"
+	"github.com/ahmadrosid/heline/core/module/solr"
+)
+
+// TestFailure tests mapping backend errors to statuses and codes
+func TestFailure(t *testing.T) {
+	testCases := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"Invalid Solr query", &solr.Error{Status: 400, Message: "syntax error"}, http.StatusBadRequest, codeInvalidQuery},
+		{"Solr error", fmt.Errorf("search: %w", &solr.Error{Status: 500}), http.StatusBadGateway, codeSolrError},
+		{"Unknown job", &IndexerError{Status: 404}, http.StatusNotFound, codeNotFound},
+		{"Indexer error", &IndexerError{Status: 500}, http.StatusBadGateway, codeIndexerError},
+		{"Timeout", &url.Error{Op: "Post", URL: "http://solr", Err: context.DeadlineExceeded}, http.StatusGatewayTimeout, codeTimeout},
+		{"Unreachable", &url.Error{Op: "Post", URL: "http://solr", Err: errors.New("connection refused")}, http.StatusBadGateway, codeUnavailable},
+		{"Other", errors.New("boom"), http.StatusInternalServerError, codeInternal},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, apiErr := failure(tc.err)
+			if status != tc.expectedStatus || apiErr.Code != tc.expectedCode {
+				t.Errorf("Expected %d %s, got %d %s", tc.expectedStatus, tc.expectedCode, status, apiErr.Code)
+			}
+		})
+	}
+}
+
+// TestErrorResponses tests the error envelope returned by the API
+func TestErrorResponses(t *testing.T) {
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintln(w, `{"responseHeader":{"status":400},"error":{"msg":"undefined field nope","code":400}}`)
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+
+	testCases := []struct {
+		name           string
+		url            string
+		requestID      string
+		expectedStatus int
+		expectedCode   string
+		expectedMsg    string
+	}{
+		{
+			name:           "Invalid query params",
+			url:            "/api/search?q=x&page=abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   codeBadRequest,
+		},
+		{
+			name:           "Unknown mode",
+			url:            "/api/search?q=x&mode=regx",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   codeBadRequest,
+			expectedMsg:    `invalid mode "regx", expected regex or files`,
+		},
+		{
+			name:           "Unknown case",
+			url:            "/api/search?q=x&case=Sensitive",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   codeBadRequest,
+			expectedMsg:    `invalid case "Sensitive", expected sensitive`,
+		},
+		{
+			name:           "Unknown word",
+			url:            "/api/search?q=x&word=exact",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   codeBadRequest,
+			expectedMsg:    `invalid word "exact", expected whole`,
+		},
+		{
+			name:           "Invalid query",
+			url:            "/api/search?q=%22unterminated",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   codeInvalidQuery,
+		},
+		{
+			name:           "Solr error body",
+			url:            "/api/search?q=solrerror",
+			requestID:      "abc123",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   codeInvalidQuery,
+			expectedMsg:    "undefined field nope",
+		},
+	}
+
+	handler := Handler(nil)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			if tc.requestID != "" {
+				req.Header.Set(requestIDHeader, tc.requestID)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			var body entity.ErrorResponse
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if body.Error.Code != tc.expectedCode {
+				t.Errorf("Expected code %s, got %s", tc.expectedCode, body.Error.Code)
+			}
+			if tc.expectedMsg != "" && body.Error.Message != tc.expectedMsg {
+				t.Errorf("Expected message %q, got %q", tc.expectedMsg, body.Error.Message)
+			}
+			if body.Error.RequestID == "" || body.Error.RequestID != rr.Header().Get(requestIDHeader) {
+				t.Errorf("Expected request id %q to match the header %q", body.Error.RequestID, rr.Header().Get(requestIDHeader))
+			}
+			if tc.requestID != "" && body.Error.RequestID != tc.requestID {
+				t.Errorf("Expected request id %q, got %q", tc.requestID, body.Error.RequestID)
+			}
+		})
+	}
+}
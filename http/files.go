@@ -0,0 +1,164 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ahmadrosid/heline/core/entity"
+	"github.com/ahmadrosid/heline/core/module/fuzzy"
+	"github.com/ahmadrosid/heline/core/module/query"
+	"github.com/ahmadrosid/heline/core/module/snippet"
+	"github.com/ahmadrosid/heline/core/module/solr"
+)
+
+// fileCandidateLimit caps how many paths matching the query are fetched
+// from Solr and ranked in the Go layer for a single file search.
+const fileCandidateLimit = 1000
+
+// fileHit is a file matched by mode=files with its fuzzy match.
+type fileHit struct {
+	item  entity.SolrField
+	match fuzzy.Result
+}
+
+// handleFileSearch serves mode=files, a "jump to file" search over paths.
+// Solr selects the files whose id contains the characters of the query in
+// order and ranks them on path_ngram by how much of the query they contain
+// in a row, so the fileCandidateLimit it returns are the likely best fuzzy
+// matches rather than arbitrary ones. Every candidate is then scored like
+// fzf and the best matches are returned first, one hit per file, with the
+// matched characters marked. Whitespace in the query is ignored. A sort
+// other than relevance keeps the matches in that order instead.
+func handleFileSearch(w http.ResponseWriter, r *http.Request, param entity.QueryParam) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	// The format was already validated by handleSearch
+	format, _ := getSnippetFormat(param)
+
+	pattern := strings.Join(strings.FieldsFunc(param.Query, unicode.IsSpace), "")
+	if pattern == "" {
+		writeError(w, http.StatusBadRequest, "query is required for mode=files")
+		return
+	}
+
+	started := time.Now()
+	result, err := solr.Search(r.Context(), solr.SolrQuery{
+		Expression:  query.BigramQuery("path_ngram", pattern),
+		Filter:      append(getQueryFilter(param), query.SubsequenceQuery("id", pattern)),
+		Limit:       fileCandidateLimit,
+		Sort:        param.Sort,
+		FacetLimits: getFacetLimits(param),
+		NoHighlight: true,
+		Debug:       param.Debug,
+	})
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+
+	var data entity.SolrResult
+	if err := json.Unmarshal(result, &data); err != nil {
+		writeInvalidResponse(w, err)
+		return
+	}
+
+	var files []fileHit
+	for _, item := range data.Response.Docs {
+		if match, ok := fuzzy.Match(pattern, item.ID); ok {
+			files = append(files, fileHit{item, match})
+		}
+	}
+	if param.Sort == solr.DefaultSort {
+		sort.SliceStable(files, func(i, j int) bool {
+			if files[i].match.Score != files[j].match.Score {
+				return files[i].match.Score > files[j].match.Score
+			}
+			// Shorter paths are closer to what was typed
+			return len(files[i].item.ID) < len(files[j].item.ID)
+		})
+	}
+
+	page, perPage := getPagination(param)
+	total := len(files)
+	start := (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+
+	var content []entity.ContentData
+	for _, file := range files[start:end] {
+		content = append(content, newContentData(file.item, fileContent(file.item.ID, file.match, format)))
+	}
+
+	response := entity.CodeSearchResult{
+		Response: entity.CodeHits{
+			Hits:    content,
+			Facets:  data.Facet,
+			Total:   total,
+			Page:    page,
+			PerPage: perPage,
+			HasMore: end < total,
+			// Only the first fileCandidateLimit paths were ranked
+			Partial: data.Header.PartialResults || data.Response.NumFound > fileCandidateLimit,
+		},
+	}
+	if param.Debug {
+		response.Debug = searchDebug(data, started)
+	}
+	enc.Encode(response)
+}
+
+// fileContent describes the fuzzy match of a path: its score, the
+// character ranges that matched, in runes like the other snippet ranges,
+// and depending on format the path as HTML
+// with the matched characters in <mark> under "path", as plain text under
+// "text", or both.
+func fileContent(path string, match fuzzy.Result, format string) entity.Map {
+	var ranges []snippet.Range
+	for _, pos := range match.Positions {
+		if n := len(ranges); n > 0 && ranges[n-1].End == pos {
+			ranges[n-1].End++
+			continue
+		}
+		ranges = append(ranges, snippet.Range{Start: pos, End: pos + 1})
+	}
+
+	content := entity.Map{
+		"score":  match.Score,
+		"ranges": ranges,
+	}
+
+	if format != formatText {
+		content["path"] = markRanges(path, ranges)
+	}
+	if format == formatText || format == formatBoth {
+		content["text"] = path
+	}
+
+	return content
+}
+
+// markRanges escapes s as HTML and wraps the characters in ranges, which
+// must be sorted and not overlap, in <mark>.
+func markRanges(s string, ranges []snippet.Range) string {
+	runes := []rune(s)
+	var b strings.Builder
+	last := 0
+	for _, rng := range ranges {
+		b.WriteString(html.EscapeString(string(runes[last:rng.Start])))
+		fmt.Fprintf(&b, "<mark>%s</mark>", html.EscapeString(string(runes[rng.Start:rng.End])))
+		last = rng.End
+	}
+	b.WriteString(html.EscapeString(string(runes[last:])))
+	return b.String()
+}
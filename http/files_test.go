@@ -0,0 +1,84 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ahmadrosid/heline/core/entity"
+)
+
+// TestFileSearch tests ranking and marking paths with mode=files
+func TestFileSearch(t *testing.T) {
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query  string   `json:"query"`
+			Filter []string `json:"filter"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		// Candidates are selected by the filter and ranked on path_ngram
+		if body.Query != "path_ngram:hd OR path_ngram:dl" {
+			t.Errorf("Unexpected query %q", body.Query)
+		}
+		if fmt.Sprint(body.Filter) != `[id:/.*[hH].*[dD].*[lL].*/]` {
+			t.Errorf("Unexpected filter %q", body.Filter)
+		}
+		if r.URL.Query().Get("hl") != "off" {
+			t.Errorf("Expected highlighting to be off")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"response":{"numFound":3,"docs":[
+			{"id":"a/heline/http/shard_list.go"},
+			{"id":"a/heline/http/handler.go"},
+			{"id":"a/heline/docs/handler<1>.md"}
+		]}}`)
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search?q=h+dl&mode=files&per_page=2", nil)
+	rr := httptest.NewRecorder()
+	handleSearch(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var result entity.CodeSearchResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	hits := result.Response
+	if hits.Total != 3 || len(hits.Hits) != 2 || !hits.HasMore {
+		t.Fatalf("Expected 2 of 3 hits, got %d of %d", len(hits.Hits), hits.Total)
+	}
+
+	expected := []string{
+		"a/heline/http/<mark>h</mark>an<mark>dl</mark>er.go",
+		"a/heline/docs/<mark>h</mark>an<mark>dl</mark>er&lt;1&gt;.md",
+	}
+	for i, hit := range hits.Hits {
+		if hit.Content["path"] != expected[i] {
+			t.Errorf("Expected hit %d to be %q, got %q", i, expected[i], hit.Content["path"])
+		}
+	}
+}
+
+// TestFileSearchEmptyQuery tests that mode=files requires a query
+func TestFileSearchEmptyQuery(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/search?q=+&mode=files", nil)
+	rr := httptest.NewRecorder()
+	handleSearch(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rr.Code)
+	}
+}
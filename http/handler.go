@@ -7,14 +7,22 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/ahmadrosid/heline/core/entity"
+	"github.com/ahmadrosid/heline/core/module/query"
+	"github.com/ahmadrosid/heline/core/module/snippet"
 	"github.com/ahmadrosid/heline/core/module/solr"
-	"github.com/ahmadrosid/heline/core/utils"
+	"github.com/ahmadrosid/heline/core/module/solr/lucene"
 	queryparam "github.com/tomwright/queryparam/v4"
 )
 
-func Handler() http.Handler {
+func Handler(config *CorsConfig) http.Handler {
+	if config == nil {
+		config = &CorsConfig{
+			allowedOrigin: "*",
+		}
+	}
 
 	mux := http.NewServeMux()
 	mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -24,6 +32,7 @@ func Handler() http.Handler {
 		})
 	}))
 	mux.HandleFunc("/api/search", handleSearch)
+	mux.HandleFunc("/api/suggest", handleSuggest)
 	
 	// Add indexer API endpoints
 	mux.HandleFunc("/api/index", handleIndexRepository)
@@ -32,38 +41,140 @@ func Handler() http.Handler {
 	
 	// Add index management endpoints
 	mux.HandleFunc("/api/index/reset", handleResetIndex)
+	mux.HandleFunc("/api/cache/stats", handleCacheStats)
 
-	return wrapCORSHandler(mux, &CorsConfig{
-		allowedOrigin: "*",
-	})
+	// Add saved search endpoints
+	savedSearches, err := openSavedSearches()
+	if err != nil {
+		fmt.Printf("Failed to open saved searches: %v\n", err)
+		mux.HandleFunc(savedSearchesPath, func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, http.StatusServiceUnavailable, "Saved searches are unavailable")
+		})
+		mux.HandleFunc(savedSearchesPath+"/", func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, http.StatusServiceUnavailable, "Saved searches are unavailable")
+		})
+	} else {
+		mux.HandleFunc(savedSearchesPath, handleSavedSearches(savedSearches))
+		mux.HandleFunc(savedSearchesPath+"/", handleSavedSearches(savedSearches))
+	}
+
+	return withRequestID(wrapCORSHandler(mux, config))
 }
 
-func getQueryFilter(param entity.QueryParam) []string {
-	var filter []string
+const (
+	defaultPerPage = 10
+	maxPerPage     = 100
+)
 
-	if len(param.Lang) > 0 {
-		filter = append(filter, fmt.Sprintf("lang:(%s)", utils.Join(param.Lang, " ", "*")))
+// getPagination normalizes the page and per_page params into a 1-based page
+// number and a page size within [1, maxPerPage].
+func getPagination(param entity.QueryParam) (page int, perPage int) {
+	page = param.Page
+	if page < 1 {
+		page = 1
 	}
 
-	if len(param.Path) > 0 {
-		filter = append(filter, fmt.Sprintf("path:(%s)", utils.Join(param.Path, " ", "*")))
+	perPage = param.PerPage
+	if perPage < 1 {
+		perPage = defaultPerPage
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
 	}
 
-	if len(param.Repo) > 0 {
-		filter = append(filter, fmt.Sprintf("repo:(%s)", utils.Join(param.Repo, " ", "*")))
+	return page, perPage
+}
+
+// maxContextLines caps the context=N param.
+const maxContextLines = 50
+
+// getContextLines returns the number of lines to show around each match.
+func getContextLines(param entity.QueryParam) int {
+	if param.Context < 0 {
+		return 0
+	}
+	if param.Context > maxContextLines {
+		return maxContextLines
+	}
+	return param.Context
+}
+
+// getQueryFilter builds the filter[...] params into filter queries. Values
+// are escaped and match the same way whether they are included or, as in
+// filter[-path]=vendor/, excluded: paths match at the start of any segment,
+// like the path: qualifier, so a directory selects every file in it, and
+// every other field matches exactly. Each
+// filter is tagged with its field so the field's facet keeps counting the
+// values that are not selected.
+func getQueryFilter(param entity.QueryParam) []string {
+	var filter []string
+
+	for _, f := range []struct {
+		field    string
+		values   []string
+		excluded []string
+		match    func(field, value string) lucene.Query
+	}{
+		{"lang", param.Lang, param.ExcludeLang, lucene.Term},
+		{"path", param.Path, param.ExcludePath, query.PathSegment},
+		{"repo", param.Repo, param.ExcludeRepo, lucene.Term},
+		{"branch", param.Branch, nil, lucene.Term},
+		{"owner_id", param.Owner, nil, lucene.Term},
+	} {
+		if len(f.values) > 0 {
+			filter = append(filter, solr.TagFilter(f.field, query.MatchFilterQuery(f.field, f.values, f.match, false)))
+		}
+		if len(f.excluded) > 0 {
+			filter = append(filter, solr.TagFilter(f.field, query.MatchFilterQuery(f.field, f.excluded, f.match, true)))
+		}
 	}
 
 	return filter
 }
 
+// getQualifierFilter builds the inline qualifiers of parsed, such as
+// lang:go, into filter queries tagged like those of getQueryFilter, so
+// the facets treat both the same.
+func getQualifierFilter(parsed *query.Query) []string {
+	var filter []string
+	for _, field := range query.Qualifiers {
+		for _, fq := range parsed.FieldFilterQueries(field) {
+			filter = append(filter, solr.TagFilter(field, fq))
+		}
+	}
+	return filter
+}
+
+// maxFacetLimit caps the facet_limit[...] params.
+const maxFacetLimit = 100
+
+// getFacetLimits returns the facet_limit[...] params keyed by field.
+// Fields without a valid limit are left out and keep their default.
+func getFacetLimits(param entity.QueryParam) map[string]int {
+	limits := map[string]int{}
+	for field, limit := range map[string]int{
+		"lang":     param.LangFacetLimit,
+		"path":     param.PathFacetLimit,
+		"repo":     param.RepoFacetLimit,
+		"branch":   param.BranchFacetLimit,
+		"owner_id": param.OwnerFacetLimit,
+	} {
+		if limit <= 0 {
+			continue
+		}
+		if limit > maxFacetLimit {
+			limit = maxFacetLimit
+		}
+		limits[field] = limit
+	}
+	return limits
+}
+
 // handleIndexRepository processes requests to index a git repository
 func handleIndexRepository(w http.ResponseWriter, r *http.Request) {
 	// Only allow POST requests
 	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Method not allowed",
-		})
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -73,19 +184,13 @@ func handleIndexRepository(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Invalid request body: " + err.Error(),
-		})
+		writeError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
 		return
 	}
 
 	// Validate the git URL
 	if req.GitURL == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Git URL is required",
-		})
+		writeError(w, http.StatusBadRequest, "Git URL is required")
 		return
 	}
 
@@ -95,10 +200,7 @@ func handleIndexRepository(w http.ResponseWriter, r *http.Request) {
 	// Send the indexing request to the indexer API
 	resp, err := client.IndexRepository(req.GitURL)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Failed to index repository: " + err.Error(),
-		})
+		writeFailure(w, fmt.Errorf("Failed to index repository: %w", err))
 		return
 	}
 
@@ -111,20 +213,14 @@ func handleIndexRepository(w http.ResponseWriter, r *http.Request) {
 func handleJobStatus(w http.ResponseWriter, r *http.Request) {
 	// Only allow GET requests
 	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Method not allowed",
-		})
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Extract the job ID from the URL path
 	jobID := strings.TrimPrefix(r.URL.Path, "/api/index/status/")
 	if jobID == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Job ID is required",
-		})
+		writeError(w, http.StatusBadRequest, "Job ID is required")
 		return
 	}
 
@@ -134,13 +230,12 @@ func handleJobStatus(w http.ResponseWriter, r *http.Request) {
 	// Get the job status from the indexer API
 	status, err := client.GetJobStatus(jobID)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Failed to get job status: " + err.Error(),
-		})
+		writeFailure(w, fmt.Errorf("Failed to get job status: %w", err))
 		return
 	}
 
+	noteCompletedJobs(*status)
+
 	// Return the response
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(status)
@@ -150,10 +245,7 @@ func handleJobStatus(w http.ResponseWriter, r *http.Request) {
 func handleListJobs(w http.ResponseWriter, r *http.Request) {
 	// Only allow GET requests
 	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Method not allowed",
-		})
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -163,13 +255,12 @@ func handleListJobs(w http.ResponseWriter, r *http.Request) {
 	// Get the list of jobs from the indexer API
 	jobs, err := client.ListJobs()
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Failed to list jobs: " + err.Error(),
-		})
+		writeFailure(w, fmt.Errorf("Failed to list jobs: %w", err))
 		return
 	}
 
+	noteCompletedJobs(jobs...)
+
 	// Return the response
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(jobs)
@@ -241,34 +332,282 @@ func enhanceHighlighting(result []byte, originalQuery string) []byte {
 	return enhancedResult
 }
 
+// newContentData wraps a Solr document and its snippet data into a hit.
+func newContentData(item entity.SolrField, content entity.Map) entity.ContentData {
+	return entity.ContentData{
+		ID: entity.Map{
+			"raw": item.ID,
+		},
+		Branch: entity.Map{
+			"raw": item.Branch,
+		},
+		OwnerID: entity.Map{
+			"raw": item.OwnerID,
+		},
+		FileID: entity.Map{
+			"raw": item.FileID,
+		},
+		Content: content,
+		Repo: entity.Map{
+			"raw": item.Repo,
+		},
+		Path: entity.Map{
+			"raw": item.Path,
+		},
+		Lang: entity.Map{
+			"raw": item.Lang,
+		},
+		FileName: entity.Map{
+			"raw": item.FileName(),
+		},
+		Extension: entity.Map{
+			"raw": item.Extension(),
+		},
+		Host: entity.Map{
+			"raw": item.Host(),
+		},
+	}
+}
+
+// withDuplicates adds the identical files collapsed into item by
+// dedupe=true to its hit.
+func withDuplicates(hit entity.ContentData, item entity.SolrField, expanded map[string]entity.SolrDoc) entity.ContentData {
+	group, ok := expanded[item.ContentHash]
+	if item.ContentHash == "" || !ok {
+		return hit
+	}
+
+	hit.Duplicates = group.NumFound
+	for _, doc := range group.Docs {
+		hit.OtherLocations = append(hit.OtherLocations, entity.Location{
+			ID:      doc.ID,
+			FileID:  doc.FileID,
+			Repo:    doc.Repo,
+			Branch:  doc.Branch,
+			OwnerID: doc.OwnerID,
+		})
+	}
+	return hit
+}
+
+// Snippet formats accepted by the format param.
+const (
+	formatHTML = "html"
+	formatText = "text"
+	formatBoth = "both"
+)
+
+// getSnippetFormat validates the format param, defaulting to HTML.
+func getSnippetFormat(param entity.QueryParam) (string, error) {
+	switch param.Format {
+	case "":
+		return formatHTML, nil
+	case formatHTML, formatText, formatBoth:
+		return param.Format, nil
+	default:
+		return "", fmt.Errorf("invalid format %q, expected text, html or both", param.Format)
+	}
+}
+
+// getSort validates the sort param, defaulting to relevance.
+func getSort(param entity.QueryParam) (string, error) {
+	if param.Sort == "" {
+		return solr.DefaultSort, nil
+	}
+	if _, ok := solr.SortOrders[param.Sort]; !ok {
+		return "", fmt.Errorf("invalid sort %q, expected relevance, path, repo or indexed_at", param.Sort)
+	}
+	return param.Sort, nil
+}
+
+// validateSearchOptions checks the mode, case and word params, which all
+// default to a plain search when empty.
+func validateSearchOptions(param entity.QueryParam) error {
+	switch param.Mode {
+	case "", "regex", "files":
+	default:
+		return fmt.Errorf("invalid mode %q, expected regex or files", param.Mode)
+	}
+	switch param.Case {
+	case "", "sensitive":
+	default:
+		return fmt.Errorf("invalid case %q, expected sensitive", param.Case)
+	}
+	switch param.Word {
+	case "", "whole":
+	default:
+		return fmt.Errorf("invalid word %q, expected whole", param.Word)
+	}
+	return nil
+}
+
+// snippetContent builds the content of a hit from its highlighted snippets,
+// adding the line span and match positions of each snippet so clients can
+// jump to file:line:col. Depending on format the snippets are returned as
+// HTML under "snippet", as plain text under "text", or both.
+func snippetContent(snippets []string, format string) entity.Map {
+	positions := make([]snippet.Position, len(snippets))
+	for i, markup := range snippets {
+		positions[i] = snippet.Locate(markup)
+	}
+
+	content := entity.Map{
+		"positions": positions,
+	}
+
+	if format != formatText {
+		content["snippet"] = snippets
+	}
+
+	if format == formatText || format == formatBoth {
+		texts := make([]snippet.Text, len(snippets))
+		for i, markup := range snippets {
+			texts[i] = snippet.ToText(markup)
+		}
+		content["text"] = texts
+	}
+
+	return content
+}
+
 func handleSearch(w http.ResponseWriter, r *http.Request) {
-	enc := json.NewEncoder(w)
 	param := entity.QueryParam{}
-	err := queryparam.Parse(r.URL.Query(), &param)
-	switch err {
-	case nil:
-		break
-	case queryparam.ErrInvalidBoolValue:
-		println("Failed parse query param")
+	if err := queryparam.Parse(r.URL.Query(), &param); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid query params: "+err.Error())
 		return
-	default:
-		println("return empty query param", err.Error())
+	}
+
+	searchWithParam(w, r, param)
+}
+
+// searchWithParam runs a search for param, whether it came from the query
+// string or from a saved search.
+func searchWithParam(w http.ResponseWriter, r *http.Request, param entity.QueryParam) {
+	enc := json.NewEncoder(w)
+
+	if param.Tbm == "docs" {
+		handleGetDocument(w, r, param.ID)
+		return
+	}
+
+	format, err := getSnippetFormat(param)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sort, err := getSort(param)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	param.Sort = sort
+
+	if err := validateSearchOptions(param); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if param.Debug && !isAdmin(r) {
+		writeError(w, http.StatusForbidden, "debug=true requires an admin token")
+		return
+	}
+
+	// Only the default search streams; the other modes build one response
+	// from verified or rescored candidates
+	if acceptsNDJSON(r) && (param.Mode != "" || param.Case != "" || param.Word != "") {
+		writeError(w, http.StatusNotAcceptable, "NDJSON streams are only supported without mode, case and word")
+		return
+	}
+
+	if param.Mode == "regex" {
+		handleRegexSearch(w, r, param)
+		return
+	}
+
+	if param.Mode == "files" {
+		handleFileSearch(w, r, param)
+		return
+	}
+
+	parsed, err := query.Parse(param.Query)
+	if err != nil {
+		apiErr := entity.APIError{
+			Code:    codeInvalidQuery,
+			Message: err.Error(),
+		}
+		if parseErr, ok := err.(*query.ParseError); ok {
+			apiErr.Details = entity.Map{
+				"position": parseErr.Pos,
+			}
+		}
+		writeAPIError(w, http.StatusBadRequest, apiErr)
+		return
+	}
+
+	caseSensitive := param.Case == "sensitive"
+	wholeWord := param.Word == "whole"
+	if caseSensitive || wholeWord {
+		// Solr narrows the candidates on the case-preserving n-grams, so
+		// "Context" still finds NewContext but not newcontext, and the
+		// verifier applies the word boundaries. Whole words are whole
+		// tokens, so the n-gram field is left out for them when case does
+		// not matter. Negated terms are only checked by the verifier, with
+		// the same case and word rules.
+		field := query.AllFields
+		if caseSensitive {
+			field = query.CaseSensitiveField
+		} else if wholeWord {
+			field = query.ContentField
+		}
+
+		handleVerifiedSearch(w, r, param, solr.SolrQuery{
+			Expression:  parsed.Prefilter(field),
+			Filter:      append(getQueryFilter(param), getQualifierFilter(parsed)...),
+			Sort:        param.Sort,
+			FacetLimits: getFacetLimits(param),
+			Dedupe:      param.Dedupe,
+		}, parsed.Patterns(caseSensitive, wholeWord), parsed.Exclusions(caseSensitive, wholeWord))
 		return
 	}
 
-	q := strings.Replace(param.Query, "*", "\\*", -1)
-	q = strings.Replace(q, "\"", "\\\"", -1)
-	q = strings.Replace(q, "'", "\\'", -1)
-	// q = "*" + q + "*"
-	println(q)
+	// The text is escaped when Solr builds the query
+	q := parsed.Text()
 
 	// Original search query for post-processing highlighting
 	originalQuery := q
 
-	result, err := solr.Search(solr.SolrQuery{
-		Query:  q,
-		Filter: getQueryFilter(param),
-	})
+	page, perPage := getPagination(param)
+	solrQuery := solr.SolrQuery{
+		Query:       q,
+		Filter:      append(getQueryFilter(param), getQualifierFilter(parsed)...),
+		Limit:       perPage,
+		Cursor:      param.Cursor,
+		Sort:        param.Sort,
+		FacetLimits: getFacetLimits(param),
+		Dedupe:      param.Dedupe,
+	}
+	if param.Cursor == "" {
+		solrQuery.Offset = (page - 1) * perPage
+	}
+	if !parsed.IsSimple() || len(parsed.Groups) == 0 {
+		solrQuery.Expression = parsed.Expression(query.AllFields)
+		solrQuery.HighlightQuery = parsed.HighlightExpression()
+	}
+
+	if acceptsNDJSON(r) {
+		// Streams have no response object to carry the debug data
+		if param.Debug {
+			writeError(w, http.StatusBadRequest, "debug=true is not supported for NDJSON streams")
+			return
+		}
+		handleStreamSearch(w, r, solrQuery, param.Export, format)
+		return
+	}
+
+	solrQuery.Debug = param.Debug
+	started := time.Now()
+	result, err := solr.Search(r.Context(), solrQuery)
 	
 	// Post-process the result to improve highlighting if needed
 	if err == nil && len(originalQuery) > 0 && strings.ContainsAny(originalQuery, ":(){}[]") {
@@ -277,10 +616,7 @@ func handleSearch(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		enc.Encode(entity.Map{
-			"error": err.Error(),
-		})
+		writeFailure(w, err)
 		return
 	}
 
@@ -288,48 +624,63 @@ func handleSearch(w http.ResponseWriter, r *http.Request) {
 	var data entity.SolrResult
 	err = dec.Decode(&data)
 	if err != nil {
-		enc.Encode(entity.Map{
-			"error": err.Error(),
-		})
+		writeInvalidResponse(w, err)
 		return
 	}
 
-	println("hints:", data.Response.NumFound, q)
-
 	w.Header().Set("Content-Type", "application/json")
+	contextLines := getContextLines(param)
+	var documents map[string]string
+	if contextLines > 0 {
+		var ids []string
+		for _, item := range data.Response.Docs {
+			if len(data.Highlight[item.ID].Content) > 0 {
+				ids = append(ids, item.ID)
+			}
+		}
+
+		documents, err = fetchDocuments(r.Context(), ids)
+		if err != nil {
+			writeFailure(w, err)
+			return
+		}
+	}
+
 	var content []entity.ContentData
 	for _, item := range data.Response.Docs {
+		// Hits without a snippet are kept, as they count towards the total
 		contents := data.Highlight[item.ID].Content
-		if len(contents) == 0 {
-			continue
+		if document, ok := documents[item.ID]; ok {
+			contents = snippet.WithContext(contents, document, contextLines)
 		}
-		content = append(content, entity.ContentData{
-			ID: entity.Map{
-				"raw": item.ID,
-			},
-			Branch: entity.Map{
-				"raw": item.Branch,
-			},
-			OwnerID: entity.Map{
-				"raw": item.OwnerID,
-			},
-			FileID: entity.Map{
-				"raw": item.FileID,
-			},
-			Content: entity.Map{
-				"snippet": contents,
-				// "snippet": contents[len(contents)-1:],
-			},
-			Repo: entity.Map{
-				"raw": item.Repo,
-			},
-		})
+		hit := newContentData(item, snippetContent(contents, format))
+		content = append(content, withDuplicates(hit, item, data.Expanded))
 	}
-	enc.Encode(entity.CodeSearchResult{
-		Response: entity.CodeHits{
-			Hits:   content,
-			Facets: data.Facet,
-			Total:  data.Response.NumFound,
-		},
-	})
+	hits := entity.CodeHits{
+		Hits:    content,
+		Facets:  data.Facet,
+		Total:   data.Response.NumFound,
+		PerPage: perPage,
+		Partial: data.Header.PartialResults,
+	}
+	if data.Response.NumFound == 0 {
+		hits.Suggestions, err = didYouMean(r.Context(), parsed.Text())
+		hits.SuggestionsFailed = err != nil
+	}
+	if param.Cursor != "" {
+		// Solr returns the same cursor once the result set is exhausted
+		hits.NextCursor = data.NextCursorMark
+		hits.HasMore = data.NextCursorMark != "" && data.NextCursorMark != param.Cursor
+	} else {
+		hits.Page = page
+		hits.HasMore = page*perPage < data.Response.NumFound
+	}
+
+	response := entity.CodeSearchResult{
+		Response: hits,
+	}
+	if param.Debug {
+		response.Debug = searchDebug(data, started)
+	}
+	enc.Encode(response)
 }
@@ -0,0 +1,299 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ahmadrosid/heline/core/entity"
+	"github.com/ahmadrosid/heline/core/module/query"
+)
+
+// TestGetPagination tests the normalization of page and per_page params
+func TestGetPagination(t *testing.T) {
+	testCases := []struct {
+		name            string
+		param           entity.QueryParam
+		expectedPage    int
+		expectedPerPage int
+	}{
+		{
+			name:            "Defaults",
+			param:           entity.QueryParam{},
+			expectedPage:    1,
+			expectedPerPage: defaultPerPage,
+		},
+		{
+			name:            "Explicit page and size",
+			param:           entity.QueryParam{Page: 3, PerPage: 25},
+			expectedPage:    3,
+			expectedPerPage: 25,
+		},
+		{
+			name:            "Negative values fall back to defaults",
+			param:           entity.QueryParam{Page: -2, PerPage: -5},
+			expectedPage:    1,
+			expectedPerPage: defaultPerPage,
+		},
+		{
+			name:            "Page size is capped",
+			param:           entity.QueryParam{PerPage: 5000},
+			expectedPage:    1,
+			expectedPerPage: maxPerPage,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, perPage := getPagination(tc.param)
+			if page != tc.expectedPage {
+				t.Errorf("Expected page %d, got %d", tc.expectedPage, page)
+			}
+			if perPage != tc.expectedPerPage {
+				t.Errorf("Expected per_page %d, got %d", tc.expectedPerPage, perPage)
+			}
+		})
+	}
+}
+
+// TestGetQueryFilter tests building tagged filter queries from filter params
+func TestGetQueryFilter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		param    entity.QueryParam
+		expected []string
+	}{
+		{
+			name:  "No filters",
+			param: entity.QueryParam{},
+		},
+		{
+			name:  "Filters are tagged with their field",
+			param: entity.QueryParam{Lang: []string{"go", "rust"}, Repo: []string{"heline"}},
+			expected: []string{
+				"{!tag=lang}lang:go OR lang:rust",
+				"{!tag=repo}repo:heline",
+			},
+		},
+		{
+			name:  "Values are escaped",
+			param: entity.QueryParam{Repo: []string{"x) OR (*:*"}, Path: []string{"ahmadrosid/heline/http/"}, Lang: []string{"c++"}},
+			expected: []string{
+				`{!tag=lang}lang:c\+\+`,
+				`{!tag=path}(path:ahmadrosid\/heline\/http\/* OR path:*\/ahmadrosid\/heline\/http\/*)`,
+				`{!tag=repo}repo:x\)\ OR\ \(\*\:\*`,
+			},
+		},
+		{
+			name:  "Branch and owner values are escaped",
+			param: entity.QueryParam{Branch: []string{"main", "feature/search"}, Owner: []string{"ahmadrosid"}},
+			expected: []string{
+				`{!tag=branch}branch:main OR branch:feature\/search`,
+				"{!tag=owner_id}owner_id:ahmadrosid",
+			},
+		},
+		{
+			name: "Exclusions are negated and escaped",
+			param: entity.QueryParam{
+				ExcludeLang: []string{"json"},
+				ExcludePath: []string{"vendor/", "gen (old)"},
+				ExcludeRepo: []string{"forks"},
+			},
+			expected: []string{
+				"{!tag=lang}-lang:json",
+				`{!tag=path}-((path:vendor\/* OR path:*\/vendor\/*) OR (path:gen\ \(old\)* OR path:*\/gen\ \(old\)*))`,
+				"{!tag=repo}-repo:forks",
+			},
+		},
+		{
+			name: "Included and excluded values match the same way",
+			param: entity.QueryParam{
+				Repo:        []string{"a/b"},
+				ExcludeRepo: []string{"a/b"},
+				Path:        []string{"heline/core/"},
+				ExcludePath: []string{"heline/core/"},
+			},
+			expected: []string{
+				`{!tag=path}(path:heline\/core\/* OR path:*\/heline\/core\/*)`,
+				`{!tag=path}-(path:heline\/core\/* OR path:*\/heline\/core\/*)`,
+				`{!tag=repo}repo:a\/b`,
+				`{!tag=repo}-repo:a\/b`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := getQueryFilter(tc.param)
+			if strings.Join(got, "\n") != strings.Join(tc.expected, "\n") {
+				t.Errorf("Expected filters %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestGetQualifierFilter tests that inline qualifiers are tagged like the
+// filter params
+func TestGetQualifierFilter(t *testing.T) {
+	parsed, err := query.Parse("lang:go -path:vendor repo:heline foo")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	expected := []string{
+		"{!tag=repo}repo:*heline*",
+		"{!tag=lang}lang:go",
+		`{!tag=path}-(path:vendor* OR path:*\/vendor*)`,
+	}
+	if got := getQualifierFilter(parsed); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected filters %q, got %q", expected, got)
+	}
+}
+
+// TestDedupe tests collapsing identical files and listing their locations
+func TestDedupe(t *testing.T) {
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Filter []string               `json:"filter"`
+			Params map[string]interface{} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		if len(body.Filter) != 1 || !strings.HasPrefix(body.Filter[0], "{!collapse field=content_hash") {
+			t.Errorf("Expected a collapse filter, got %v", body.Filter)
+		}
+		if body.Params["expand"] != "true" {
+			t.Errorf("Expected expand=true, got %v", body.Params["expand"])
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{
+			"response":{"numFound":1,"docs":[{"id":"a/heline/main.go","content_hash":"f00"}]},
+			"highlighting":{"a/heline/main.go":{"content":["<mark>x</mark>"]}},
+			"expanded":{"f00":{"numFound":2,"docs":[{"id":"b/heline/main.go","repo":"b/heline"},{"id":"c/heline/main.go","repo":"c/heline"}]}}
+		}`)
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search?q=dedupe&dedupe=true", nil)
+	rr := httptest.NewRecorder()
+	handleSearch(rr, req)
+
+	var result entity.CodeSearchResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(result.Response.Hits) != 1 {
+		t.Fatalf("Expected 1 hit, got %d", len(result.Response.Hits))
+	}
+
+	hit := result.Response.Hits[0]
+	if hit.Duplicates != 2 || len(hit.OtherLocations) != 2 || hit.OtherLocations[1].Repo != "c/heline" {
+		t.Errorf("Expected 2 other locations, got %d: %+v", hit.Duplicates, hit.OtherLocations)
+	}
+}
+
+// TestHitsWithoutSnippets tests that hits Solr matched but could not
+// highlight, such as partial identifier matches, are still returned
+func TestHitsWithoutSnippets(t *testing.T) {
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("hl.defaultSummary") != "true" {
+			t.Errorf("Expected hl.defaultSummary=true")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{
+			"response":{"numFound":2,"docs":[{"id":"a"},{"id":"b"}]},
+			"highlighting":{"a":{"content":["<mark>x</mark>"]},"b":{}}
+		}`)
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search?q=ngram", nil)
+	rr := httptest.NewRecorder()
+	handleSearch(rr, req)
+
+	var result entity.CodeSearchResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(result.Response.Hits) != result.Response.Total {
+		t.Errorf("Expected all %d hits, got %d", result.Response.Total, len(result.Response.Hits))
+	}
+}
+
+// TestNewContentData tests the file details returned with every hit
+func TestNewContentData(t *testing.T) {
+	testCases := []struct {
+		name      string
+		item      entity.SolrField
+		fileName  string
+		extension string
+		host      string
+	}{
+		{
+			name:      "GitHub file",
+			item:      entity.SolrField{FileID: "github.com/ahmadrosid/heline/http/handler.go", Path: "ahmadrosid/heline/http", Lang: "go"},
+			fileName:  "handler.go",
+			extension: "go",
+			host:      "github.com",
+		},
+		{
+			name:      "Self-hosted with port",
+			item:      entity.SolrField{FileID: "git.example.com:8443/team/app/web/index.test.tsx"},
+			fileName:  "index.test.tsx",
+			extension: "tsx",
+			host:      "git.example.com:8443",
+		},
+		{
+			name:     "No extension",
+			item:     entity.SolrField{FileID: "gitlab.com/team/app/Makefile"},
+			fileName: "Makefile",
+			host:     "gitlab.com",
+		},
+		{
+			name:     "Dotfile",
+			item:     entity.SolrField{FileID: "github.com/team/app/.gitignore"},
+			fileName: ".gitignore",
+			host:     "github.com",
+		},
+		{
+			name:      "No host",
+			item:      entity.SolrField{FileID: "team/app/main.rs"},
+			fileName:  "main.rs",
+			extension: "rs",
+		},
+		{
+			name: "No file id",
+			item: entity.SolrField{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hit := newContentData(tc.item, entity.Map{})
+			if hit.Path["raw"] != tc.item.Path || hit.Lang["raw"] != tc.item.Lang {
+				t.Errorf("Expected path %q and lang %q, got %v and %v", tc.item.Path, tc.item.Lang, hit.Path["raw"], hit.Lang["raw"])
+			}
+			if hit.FileName["raw"] != tc.fileName {
+				t.Errorf("Expected file name %q, got %v", tc.fileName, hit.FileName["raw"])
+			}
+			if hit.Extension["raw"] != tc.extension {
+				t.Errorf("Expected extension %q, got %v", tc.extension, hit.Extension["raw"])
+			}
+			if hit.Host["raw"] != tc.host {
+				t.Errorf("Expected host %q, got %v", tc.host, hit.Host["raw"])
+			}
+		})
+	}
+}
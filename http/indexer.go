@@ -28,6 +28,16 @@ type IndexResponse struct {
 	JobID   *string `json:"job_id,omitempty"`
 }
 
+// IndexerError is an unexpected response from the indexer API.
+type IndexerError struct {
+	Status int
+	Body   string
+}
+
+func (e *IndexerError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d, body: %s", e.Status, e.Body)
+}
+
 // JobStatus represents the status of an indexing job
 type JobStatus struct {
 	GitURL      string     `json:"git_url"`
@@ -83,7 +93,7 @@ func (c *IndexerClient) IndexRepository(gitURL string) (*IndexResponse, error) {
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+		return nil, &IndexerError{Status: resp.StatusCode, Body: string(body)}
 	}
 
 	var indexResp IndexResponse
@@ -109,7 +119,7 @@ func (c *IndexerClient) GetJobStatus(jobID string) (*JobStatus, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+		return nil, &IndexerError{Status: resp.StatusCode, Body: string(body)}
 	}
 
 	var jobStatus JobStatus
@@ -146,7 +156,7 @@ func (c *IndexerClient) ListJobs() ([]JobStatus, error) {
 	
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+		return nil, &IndexerError{Status: resp.StatusCode, Body: string(body)}
 	}
 
 	var jobs []JobStatus
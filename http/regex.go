@@ -0,0 +1,41 @@
+package http
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/ahmadrosid/heline/core/entity"
+	"github.com/ahmadrosid/heline/core/module/query"
+	"github.com/ahmadrosid/heline/core/module/solr"
+)
+
+// handleRegexSearch serves mode=regex. Solr narrows the candidates using
+// literals extracted from the pattern, then every candidate is verified
+// with Go's regexp against its reassembled plain text.
+func handleRegexSearch(w http.ResponseWriter, r *http.Request, param entity.QueryParam) {
+	re, err := regexp.Compile(param.Query)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, entity.APIError{
+			Code:    codeInvalidQuery,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	prefilter, err := query.RegexPrefilter(param.Query)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, entity.APIError{
+			Code:    codeInvalidQuery,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	handleVerifiedSearch(w, r, param, solr.SolrQuery{
+		Expression:  prefilter,
+		Filter:      getQueryFilter(param),
+		Sort:        param.Sort,
+		FacetLimits: getFacetLimits(param),
+		Dedupe:      param.Dedupe,
+	}, []*regexp.Regexp{re}, nil)
+}
@@ -1,7 +1,9 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/ahmadrosid/heline/core/entity"
@@ -12,10 +14,7 @@ import (
 func handleResetIndex(w http.ResponseWriter, r *http.Request) {
 	// Only allow POST requests
 	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(entity.Map{
-			"error": "Method not allowed. Use POST.",
-		})
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed. Use POST.")
 		return
 	}
 
@@ -36,14 +35,11 @@ func handleResetIndex(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Reset the index
-	err := solr.ResetIndex(requestBody.RecreateSchema)
+	// Reset the index. It is not tied to the request context, since
+	// abandoning a reset half way could leave the core unloaded.
+	err := solr.ResetIndex(context.Background(), requestBody.RecreateSchema)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(entity.Map{
-			"error":   "Failed to reset index",
-			"details": err.Error(),
-		})
+		writeFailure(w, fmt.Errorf("Failed to reset index: %w", err))
 		return
 	}
 
@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -12,7 +13,7 @@ import (
 )
 
 // MockResetIndex is a mock function for solr.ResetIndex
-type MockResetIndex func(recreateSchema bool) error
+type MockResetIndex func(ctx context.Context, recreateSchema bool) error
 
 // TestHandleResetIndex tests the handleResetIndex function
 func TestHandleResetIndex(t *testing.T) {
@@ -37,7 +38,7 @@ func TestHandleResetIndex(t *testing.T) {
 			requestBody: map[string]interface{}{
 				"recreate_schema": false,
 			},
-			mockResetIndex: func(recreateSchema bool) error {
+			mockResetIndex: func(ctx context.Context, recreateSchema bool) error {
 				if recreateSchema != false {
 					t.Errorf("Expected recreateSchema to be false, got %v", recreateSchema)
 				}
@@ -58,7 +59,7 @@ func TestHandleResetIndex(t *testing.T) {
 			requestBody: map[string]interface{}{
 				"recreate_schema": true,
 			},
-			mockResetIndex: func(recreateSchema bool) error {
+			mockResetIndex: func(ctx context.Context, recreateSchema bool) error {
 				if recreateSchema != true {
 					t.Errorf("Expected recreateSchema to be true, got %v", recreateSchema)
 				}
@@ -77,7 +78,7 @@ func TestHandleResetIndex(t *testing.T) {
 			name:        "Empty POST request (default values)",
 			method:      http.MethodPost,
 			requestBody: nil,
-			mockResetIndex: func(recreateSchema bool) error {
+			mockResetIndex: func(ctx context.Context, recreateSchema bool) error {
 				if recreateSchema != false {
 					t.Errorf("Expected recreateSchema to be false, got %v", recreateSchema)
 				}
@@ -99,7 +100,25 @@ func TestHandleResetIndex(t *testing.T) {
 			mockResetIndex: nil,
 			expectedStatus: http.StatusMethodNotAllowed,
 			expectedBody: map[string]interface{}{
-				"error": "Method not allowed. Use POST.",
+				"error": map[string]interface{}{
+					"code":    "method_not_allowed",
+					"message": "Method not allowed. Use POST.",
+				},
+			},
+		},
+		{
+			name:        "Reset fails",
+			method:      http.MethodPost,
+			requestBody: nil,
+			mockResetIndex: func(ctx context.Context, recreateSchema bool) error {
+				return &solr.Error{Status: http.StatusServiceUnavailable, Message: "core is loading"}
+			},
+			expectedStatus: http.StatusBadGateway,
+			expectedBody: map[string]interface{}{
+				"error": map[string]interface{}{
+					"code":    "solr_error",
+					"message": "core is loading",
+				},
 			},
 		},
 	}
@@ -186,7 +205,7 @@ func TestIntegrationWithHandler(t *testing.T) {
 
 	// Mock the ResetIndex function
 	resetCalled := false
-	solr.ResetIndex = func(recreateSchema bool) error {
+	solr.ResetIndex = func(ctx context.Context, recreateSchema bool) error {
 		resetCalled = true
 		return nil
 	}
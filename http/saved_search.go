@@ -0,0 +1,183 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ahmadrosid/heline/core/entity"
+	"github.com/ahmadrosid/heline/core/module/query"
+	"github.com/ahmadrosid/heline/core/module/savedsearch"
+	queryparam "github.com/tomwright/queryparam/v4"
+)
+
+const savedSearchesPath = "/api/saved-searches"
+
+// openSavedSearches opens the saved search store configured with the
+// SAVED_SEARCHES_PATH environment variable.
+func openSavedSearches() (*savedsearch.Store, error) {
+	path := os.Getenv("SAVED_SEARCHES_PATH")
+	if path == "" {
+		path = savedsearch.DefaultPath
+	}
+	return savedsearch.Open(path)
+}
+
+// handleSavedSearches serves the saved search API:
+//
+//	GET    /api/saved-searches           list, optionally ?owner=
+//	POST   /api/saved-searches           create
+//	GET    /api/saved-searches/{id}      get
+//	PUT    /api/saved-searches/{id}      replace
+//	DELETE /api/saved-searches/{id}      delete
+//	GET    /api/saved-searches/{id}/run  run, accepting page and per_page
+//
+// Owners are not authenticated, so every endpoint requires the admin token.
+func handleSavedSearches(store *savedsearch.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(r) {
+			writeError(w, http.StatusForbidden, "saved searches require an admin token")
+			return
+		}
+
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, savedSearchesPath), "/")
+		parts := strings.Split(path, "/")
+
+		switch {
+		case path == "":
+			switch r.Method {
+			case http.MethodGet:
+				writeJSON(w, http.StatusOK, store.List(r.URL.Query().Get("owner")))
+			case http.MethodPost:
+				createSavedSearch(w, r, store)
+			default:
+				writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+
+		case len(parts) == 1:
+			switch r.Method {
+			case http.MethodGet:
+				search, err := store.Get(parts[0])
+				if err != nil {
+					writeStoreError(w, err)
+					return
+				}
+				writeJSON(w, http.StatusOK, search)
+			case http.MethodPut:
+				updateSavedSearch(w, r, store, parts[0])
+			case http.MethodDelete:
+				if err := store.Delete(parts[0]); err != nil {
+					writeStoreError(w, err)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+
+		case len(parts) == 2 && parts[1] == "run":
+			if r.Method != http.MethodGet && r.Method != http.MethodPost {
+				writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+				return
+			}
+			runSavedSearch(w, r, store, parts[0])
+
+		default:
+			writeError(w, http.StatusNotFound, "Not found")
+		}
+	}
+}
+
+func createSavedSearch(w http.ResponseWriter, r *http.Request, store *savedsearch.Store) {
+	search, err := decodeSavedSearch(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	search, err = store.Create(search)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, search)
+}
+
+func updateSavedSearch(w http.ResponseWriter, r *http.Request, store *savedsearch.Store, id string) {
+	search, err := decodeSavedSearch(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	search, err = store.Update(id, search)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, search)
+}
+
+// runSavedSearch runs the saved search like /api/search would, taking
+// only the paging params from the request.
+func runSavedSearch(w http.ResponseWriter, r *http.Request, store *savedsearch.Store, id string) {
+	search, err := store.Get(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	var paging struct {
+		Page    int `queryparam:"page"`
+		PerPage int `queryparam:"per_page"`
+	}
+	if err := queryparam.Parse(r.URL.Query(), &paging); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	param := search.QueryParam()
+	param.Page = paging.Page
+	param.PerPage = paging.PerPage
+	searchWithParam(w, r, param)
+}
+
+// decodeSavedSearch reads a saved search from the request body and checks
+// that it can be run.
+func decodeSavedSearch(r *http.Request) (entity.SavedSearch, error) {
+	var search entity.SavedSearch
+	if err := json.NewDecoder(r.Body).Decode(&search); err != nil {
+		return search, fmt.Errorf("Invalid request body: %s", err.Error())
+	}
+
+	if strings.TrimSpace(search.Name) == "" {
+		return search, fmt.Errorf("Name is required")
+	}
+	if strings.TrimSpace(search.Query) == "" {
+		return search, fmt.Errorf("Query is required")
+	}
+	if _, err := query.Parse(search.Query); err != nil {
+		return search, fmt.Errorf("Invalid query: %s", err.Error())
+	}
+	if _, err := getSort(search.QueryParam()); err != nil {
+		return search, err
+	}
+
+	return search, nil
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	if err == savedsearch.ErrNotFound {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
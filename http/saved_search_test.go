@@ -0,0 +1,105 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ahmadrosid/heline/core/entity"
+	"github.com/ahmadrosid/heline/core/module/savedsearch"
+)
+
+// TestSavedSearches tests the saved search CRUD endpoints and running a
+// saved search against Solr
+func TestSavedSearches(t *testing.T) {
+	var filters []string
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Filter []string `json:"filter"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		filters = body.Filter
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"response":{"numFound":1,"docs":[{"id":"a"}]},"highlighting":{"a":{"content":["<mark>x</mark>"]}}}`)
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+	os.Setenv("ADMIN_TOKEN", "secret")
+	defer os.Unsetenv("ADMIN_TOKEN")
+
+	store, err := savedsearch.Open(filepath.Join(t.TempDir(), "saved.json"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	handler := handleSavedSearches(store)
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/saved-searches", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d without the admin token, got %d", http.StatusForbidden, rr.Code)
+	}
+
+	rr = do(http.MethodPost, "/api/saved-searches", `{"name":"audit"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a search without query, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	rr = do(http.MethodPost, "/api/saved-searches", `{"name":"audit","query":"\"unterminated"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid query, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	rr = do(http.MethodPost, "/api/saved-searches", `{"name":"audit","query":"exec","filters":{"lang":["go"]},"sort":"path","owner":"sec"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	var created entity.SavedSearch
+	json.NewDecoder(rr.Body).Decode(&created)
+
+	rr = do(http.MethodGet, "/api/saved-searches?owner=sec", "")
+	var list []entity.SavedSearch
+	json.NewDecoder(rr.Body).Decode(&list)
+	if len(list) != 1 || list[0].ID != created.ID {
+		t.Errorf("Expected list with %s, got %+v", created.ID, list)
+	}
+
+	rr = do(http.MethodGet, "/api/saved-searches/"+created.ID+"/run", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d running search, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var result entity.CodeSearchResult
+	json.NewDecoder(rr.Body).Decode(&result)
+	if len(result.Response.Hits) != 1 {
+		t.Errorf("Expected 1 hit, got %d", len(result.Response.Hits))
+	}
+	if len(filters) != 1 || !strings.Contains(filters[0], "lang:go") {
+		t.Errorf("Expected saved lang filter to reach Solr, got %v", filters)
+	}
+
+	rr = do(http.MethodDelete, "/api/saved-searches/"+created.ID, "")
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+
+	rr = do(http.MethodGet, "/api/saved-searches/"+created.ID, "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d after delete, got %d", http.StatusNotFound, rr.Code)
+	}
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ahmadrosid/heline/core/entity"
+	"github.com/ahmadrosid/heline/core/module/solr"
+)
+
+// didYouMean returns corrected versions of text for a search that found
+// nothing. Spellcheck is best effort: a failure is returned for the
+// response to flag, and the search still succeeds without suggestions.
+func didYouMean(ctx context.Context, text string) ([]string, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	result, err := solr.Spellcheck(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+
+	var data entity.SolrSpellcheckResult
+	if err := json.Unmarshal(result, &data); err != nil {
+		return nil, err
+	}
+
+	var suggestions []string
+	collations := data.Spellcheck.Collations
+	for i := 0; i+1 < len(collations); i += 2 {
+		if suggestion, ok := collations[i+1].(string); ok && suggestion != text {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// TestDidYouMean tests reading corrected queries from spellcheck collations
+func TestDidYouMean(t *testing.T) {
+	failing := false
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/solr/heline/didyoumean" {
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+		if failing {
+			http.Error(w, "spellcheck is not configured", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"spellcheck":{"suggestions":[],"collations":["collation","handleSearch","collation",%q]}}`, r.URL.Query().Get("spellcheck.q"))
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+
+	got, err := didYouMean(context.Background(), "hanldeSearch")
+	if err != nil || fmt.Sprint(got) != "[handleSearch]" {
+		t.Errorf("Expected [handleSearch], got %v, %v", got, err)
+	}
+
+	if got, err := didYouMean(context.Background(), ""); got != nil || err != nil {
+		t.Errorf("Expected no suggestions for empty text, got %v, %v", got, err)
+	}
+
+	failing = true
+	if got, err := didYouMean(context.Background(), "hanldeSearch"); got != nil || err == nil {
+		t.Errorf("Expected a failed spellcheck to return an error, got %v, %v", got, err)
+	}
+}
@@ -0,0 +1,158 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ahmadrosid/heline/core/entity"
+	"github.com/ahmadrosid/heline/core/module/solr"
+	"github.com/ahmadrosid/heline/core/utils"
+)
+
+const ndjsonContentType = "application/x-ndjson"
+
+// streamBatchSize is the number of hits fetched per cursorMark page when
+// streaming highlighted results.
+const streamBatchSize = 100
+
+func acceptsNDJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), ndjsonContentType)
+}
+
+// handleStreamSearch writes every hit for solrQuery as one JSON object per
+// line, flushing as it goes so memory use stays flat however many hits
+// there are. By default hits carry snippets and are fetched in cursorMark
+// batches; with export set they come from Solr's /export handler, which is
+// faster for very large result sets but returns metadata only.
+func handleStreamSearch(w http.ResponseWriter, r *http.Request, solrQuery solr.SolrQuery, export bool, format string) {
+	w.Header().Set("Content-Type", ndjsonContentType)
+	enc := utils.NewNDJSONStreamEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	var err error
+	if export {
+		err = streamExport(r.Context(), enc, solrQuery)
+	} else {
+		err = streamCursor(r.Context(), enc, flusher, solrQuery, format)
+	}
+
+	// Headers are already sent, so the error is reported as the last line
+	if err != nil {
+		_, apiErr := failure(err)
+		apiErr.RequestID = requestID(w)
+		enc.EncodeRow(entity.ErrorResponse{
+			Error: apiErr,
+		})
+	}
+	enc.Close()
+}
+
+func streamCursor(ctx context.Context, enc *utils.StreamEncoder, flusher http.Flusher, solrQuery solr.SolrQuery, format string) error {
+	solrQuery.Cursor = "*"
+	solrQuery.Offset = 0
+	solrQuery.Limit = streamBatchSize
+
+	for {
+		result, err := solr.Search(ctx, solrQuery)
+		if err != nil {
+			return err
+		}
+
+		var data entity.SolrResult
+		if err := json.Unmarshal(result, &data); err != nil {
+			return err
+		}
+
+		for _, item := range data.Response.Docs {
+			// Hits without a snippet are streamed too, since Solr matched them
+			contents := data.Highlight[item.ID].Content
+
+			hit := newContentData(item, snippetContent(contents, format))
+			err := enc.EncodeRow(withDuplicates(hit, item, data.Expanded))
+			if err != nil {
+				return err
+			}
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		// Solr returns the same cursor once the result set is exhausted
+		if data.NextCursorMark == "" || data.NextCursorMark == solrQuery.Cursor {
+			return nil
+		}
+		solrQuery.Cursor = data.NextCursorMark
+	}
+}
+
+func streamExport(ctx context.Context, enc *utils.StreamEncoder, solrQuery solr.SolrQuery) error {
+	body, err := solr.Export(ctx, solrQuery)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	dec := json.NewDecoder(body)
+	if err := seekDocs(dec); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		var item struct {
+			entity.SolrField
+			Exception string `json:"EXCEPTION"`
+		}
+		if err := dec.Decode(&item); err != nil {
+			return err
+		}
+
+		if item.Exception != "" {
+			return fmt.Errorf("solr export failed: %s", item.Exception)
+		}
+
+		if err := enc.EncodeRow(newContentData(item.SolrField, entity.Map{})); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// seekDocs advances dec to the first element of the response.docs array
+// without buffering the rest of the body.
+func seekDocs(dec *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			return fmt.Errorf("solr export response has no docs")
+		}
+		if err != nil {
+			return err
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		case "docs":
+			if depth != 2 {
+				continue
+			}
+			token, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if token != json.Delim('[') {
+				return fmt.Errorf("solr export response docs is not an array")
+			}
+			return nil
+		}
+	}
+}
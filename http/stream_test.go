@@ -0,0 +1,108 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ahmadrosid/heline/core/entity"
+)
+
+// TestStreamSearch tests NDJSON streaming over cursor pages and /export
+func TestStreamSearch(t *testing.T) {
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/solr/heline/select":
+			var body struct {
+				Params map[string]interface{} `json:"params"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+
+			// Two pages of one hit each, then an exhausted cursor
+			switch body.Params["cursorMark"] {
+			case "*":
+				fmt.Fprintln(w, `{"response":{"numFound":2,"docs":[{"id":"a"}]},"highlighting":{"a":{"content":["<mark>x</mark>"]}},"nextCursorMark":"c1"}`)
+			case "c1":
+				fmt.Fprintln(w, `{"response":{"numFound":2,"docs":[{"id":"b"}]},"highlighting":{"b":{"content":["<mark>y</mark>"]}},"nextCursorMark":"c2"}`)
+			default:
+				fmt.Fprintln(w, `{"response":{"numFound":2,"docs":[]},"nextCursorMark":"c2"}`)
+			}
+
+		case "/solr/heline/export":
+			fmt.Fprintln(w, `{"responseHeader":{"status":0},"response":{"numFound":3,"docs":[{"id":"a"},{"id":"b"},{"id":"c"}]}}`)
+
+		default:
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+
+	testCases := []struct {
+		name        string
+		url         string
+		expectedIDs []string
+	}{
+		{"Cursor batches", "/api/search?q=foo", []string{"a", "b"}},
+		{"Export handler", "/api/search?q=foo&export=true", []string{"a", "b", "c"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			req.Header.Set("Accept", "application/x-ndjson")
+			rr := httptest.NewRecorder()
+
+			handleSearch(rr, req)
+
+			if ct := rr.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+				t.Errorf("Expected Content-Type application/x-ndjson, got %s", ct)
+			}
+
+			var ids []string
+			scanner := bufio.NewScanner(rr.Body)
+			for scanner.Scan() {
+				var hit entity.ContentData
+				if err := json.Unmarshal(scanner.Bytes(), &hit); err != nil {
+					t.Fatalf("Failed to decode line %q: %v", scanner.Text(), err)
+				}
+				ids = append(ids, fmt.Sprint(hit.ID["raw"]))
+			}
+
+			if fmt.Sprint(ids) != fmt.Sprint(tc.expectedIDs) {
+				t.Errorf("Expected hits %v, got %v", tc.expectedIDs, ids)
+			}
+		})
+	}
+}
+
+// TestStreamSearchNotAcceptable tests that modes which cannot stream refuse
+// NDJSON instead of returning JSON
+func TestStreamSearchNotAcceptable(t *testing.T) {
+	for _, url := range []string{
+		"/api/search?q=%5Cd%2B&mode=regex",
+		"/api/search?q=main&mode=files",
+		"/api/search?q=Context&case=sensitive",
+		"/api/search?q=id&word=whole",
+	} {
+		t.Run(url, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			req.Header.Set("Accept", "application/x-ndjson")
+			rr := httptest.NewRecorder()
+
+			handleSearch(rr, req)
+
+			if rr.Code != http.StatusNotAcceptable {
+				t.Errorf("Expected status %d, got %d: %s", http.StatusNotAcceptable, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ahmadrosid/heline/core/entity"
+	"github.com/ahmadrosid/heline/core/module/solr"
+	queryparam "github.com/tomwright/queryparam/v4"
+)
+
+const (
+	defaultSuggestLimit = 5
+	maxSuggestLimit     = 20
+)
+
+// handleSuggest completes the search box input with identifiers, repos and
+// paths, most frequent first. limit applies to each kind of suggestion.
+func handleSuggest(w http.ResponseWriter, r *http.Request) {
+	var param struct {
+		Query string `queryparam:"q"`
+		Limit int    `queryparam:"limit"`
+	}
+	if err := queryparam.Parse(r.URL.Query(), &param); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	prefix := strings.TrimSpace(param.Query)
+	if prefix == "" {
+		writeJSON(w, http.StatusOK, entity.SuggestResult{Suggestions: []entity.Suggestion{}})
+		return
+	}
+
+	limit := param.Limit
+	if limit < 1 {
+		limit = defaultSuggestLimit
+	}
+	if limit > maxSuggestLimit {
+		limit = maxSuggestLimit
+	}
+
+	result, err := solr.Suggest(r.Context(), prefix, limit)
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+
+	var data entity.SolrTermsResult
+	if err := json.Unmarshal(result, &data); err != nil {
+		writeInvalidResponse(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entity.SuggestResult{Suggestions: suggestions(data)})
+}
+
+// suggestions flattens the terms of each suggest field into one list,
+// most frequent first.
+func suggestions(data entity.SolrTermsResult) []entity.Suggestion {
+	list := []entity.Suggestion{}
+	for _, field := range solr.SuggestFields {
+		terms := data.Terms[field]
+		for i := 0; i+1 < len(terms); i += 2 {
+			value, ok := terms[i].(string)
+			count, _ := terms[i+1].(float64)
+			if !ok || count == 0 {
+				continue
+			}
+			list = append(list, entity.Suggestion{
+				Value: value,
+				Type:  field,
+				Count: int(count),
+			})
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Count > list[j].Count
+	})
+	return list
+}
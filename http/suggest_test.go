@@ -0,0 +1,104 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ahmadrosid/heline/core/entity"
+)
+
+// mockTermsHandler serves terms like Solr's terms handler, which only reads
+// terms.prefix and terms.regex as global params and ignores f.<field>.
+// overrides.
+func mockTermsHandler(t *testing.T, index map[string][]interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/solr/heline/terms" {
+			t.Errorf("Unexpected request to %s", r.URL.Path)
+		}
+		params := r.URL.Query()
+
+		var match *regexp.Regexp
+		if re := params.Get("terms.regex"); re != "" {
+			if params.Get("terms.regex.flag") == "case_insensitive" {
+				re = "(?i)" + re
+			}
+			match = regexp.MustCompile("^(?:" + re + ")$")
+		}
+
+		terms := map[string][]interface{}{}
+		for _, field := range params["terms.fl"] {
+			list := []interface{}{}
+			for i := 0; i+1 < len(index[field]); i += 2 {
+				term := index[field][i].(string)
+				if !strings.HasPrefix(term, params.Get("terms.prefix")) {
+					continue
+				}
+				if match != nil && !match.MatchString(term) {
+					continue
+				}
+				list = append(list, term, index[field][i+1])
+			}
+			terms[field] = list
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entity.SolrTermsResult{Terms: terms})
+	}
+}
+
+// TestSuggest tests merging identifier, repo and path terms by frequency
+func TestSuggest(t *testing.T) {
+	mockSolr := httptest.NewServer(mockTermsHandler(t, map[string][]interface{}{
+		"identifier": {"err", 90, "helper", 40, "hello", 3, "Helper", 2},
+		"repo":       {"golang/go", 50, "ahmadrosid/heline", 12},
+		"path":       {"golang/go/main.go", 50, "golang/go/help/Hello.go", 1},
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/suggest?q=hel", nil)
+	rr := httptest.NewRecorder()
+	handleSuggest(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var result entity.SuggestResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	expected := []entity.Suggestion{
+		{Value: "helper", Type: "identifier", Count: 40},
+		{Value: "ahmadrosid/heline", Type: "repo", Count: 12},
+		{Value: "hello", Type: "identifier", Count: 3},
+		{Value: "golang/go/help/Hello.go", Type: "path", Count: 1},
+	}
+	if fmt.Sprint(result.Suggestions) != fmt.Sprint(expected) {
+		t.Errorf("Expected suggestions %v, got %v", expected, result.Suggestions)
+	}
+
+	// Every completion starts with q, or has a segment that does
+	for _, s := range result.Suggestions {
+		segments := []string{s.Value}
+		if s.Type != "identifier" {
+			segments = strings.Split(strings.ToLower(s.Value), "/")
+		}
+		completes := false
+		for _, segment := range segments {
+			completes = completes || strings.HasPrefix(segment, "hel")
+		}
+		if !completes {
+			t.Errorf("Suggestion %q does not complete hel", s.Value)
+		}
+	}
+}
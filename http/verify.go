@@ -0,0 +1,178 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ahmadrosid/heline/core/entity"
+	"github.com/ahmadrosid/heline/core/module/snippet"
+	"github.com/ahmadrosid/heline/core/module/solr"
+)
+
+const (
+	// verifyCandidateLimit caps how many Solr candidates are fetched and
+	// verified in the Go layer for a single search.
+	verifyCandidateLimit = 2000
+	// verifyBatchSize is the number of candidates fetched per cursorMark
+	// page.
+	verifyBatchSize = 100
+	// verifyMaxMatches caps the matches collected from one document.
+	verifyMaxMatches = 1000
+	// verifyMaxSnippets mirrors hl.snippets used for content search.
+	verifyMaxSnippets = 3
+)
+
+// handleVerifiedSearch pages through the candidates for solrQuery with
+// their full content and keeps the documents where every pattern and none
+// of exclusions match the plain text. Snippets and match positions come from the patterns
+// rather than Solr highlighting, so they are exact.
+//
+// Candidates are fetched in cursorMark batches until the requested page
+// and one more hit are verified, the candidates run out, or
+// verifyCandidateLimit candidates were checked. Unless the candidates ran
+// out, total only counts the hits verified so far and partial is set.
+func handleVerifiedSearch(w http.ResponseWriter, r *http.Request, param entity.QueryParam, solrQuery solr.SolrQuery, patterns, exclusions []*regexp.Regexp) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	// The format was already validated by handleSearch
+	format, _ := getSnippetFormat(param)
+
+	page, perPage := getPagination(param)
+	// One more hit than the page shows tells whether there are more
+	wanted := page*perPage + 1
+
+	solrQuery.Cursor = "*"
+	solrQuery.Offset = 0
+	solrQuery.Limit = verifyBatchSize
+	solrQuery.WithContent = true
+	solrQuery.Debug = param.Debug
+
+	started := time.Now()
+	var first entity.SolrResult
+	var content []entity.ContentData
+	partial := false
+	exhausted := false
+	for checked := 0; checked < verifyCandidateLimit && len(content) < wanted; {
+		result, err := solr.Search(r.Context(), solrQuery)
+		if err != nil {
+			writeFailure(w, err)
+			return
+		}
+
+		var data entity.SolrResult
+		if err := json.Unmarshal(result, &data); err != nil {
+			writeInvalidResponse(w, err)
+			return
+		}
+		if checked == 0 {
+			first = data
+		}
+		partial = partial || data.Header.PartialResults
+		checked += len(data.Response.Docs)
+
+		for _, item := range data.Response.Docs {
+			lines := snippet.ParseRows(strings.Join(item.Content, ""))
+			ranges := verifyPatterns(lines, patterns, exclusions)
+			if ranges == nil {
+				continue
+			}
+
+			hit := newContentData(item, verifiedSnippets(lines, ranges, getContextLines(param), format))
+			content = append(content, withDuplicates(hit, item, data.Expanded))
+		}
+
+		// Solr returns the same cursor once the result set is exhausted
+		if len(data.Response.Docs) == 0 || data.NextCursorMark == "" || data.NextCursorMark == solrQuery.Cursor {
+			exhausted = true
+			break
+		}
+		solrQuery.Cursor = data.NextCursorMark
+	}
+
+	total := len(content)
+	start := (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+
+	response := entity.CodeSearchResult{
+		Response: entity.CodeHits{
+			Hits:    content[start:end],
+			Facets:  first.Facet,
+			Total:   total,
+			Page:    page,
+			PerPage: perPage,
+			HasMore: end < total,
+			// Candidates left unchecked may hold more hits
+			Partial: partial || !exhausted,
+		},
+	}
+	if param.Debug {
+		response.Debug = searchDebug(first, started)
+	}
+	enc.Encode(response)
+}
+
+// verifyPatterns returns the match ranges of all patterns in lines, or nil
+// when any pattern has no non-empty match or any exclusion matches.
+func verifyPatterns(lines []snippet.Line, patterns, exclusions []*regexp.Regexp) map[int][]snippet.Range {
+	text := snippet.PlainText(lines)
+	for _, re := range exclusions {
+		if re.MatchString(text) {
+			return nil
+		}
+	}
+
+	ranges := map[int][]snippet.Range{}
+
+	for _, re := range patterns {
+		found := snippet.LineRanges(lines, re.FindAllStringIndex(text, verifyMaxMatches))
+		if len(found) == 0 {
+			return nil
+		}
+
+		for number, lineRanges := range found {
+			ranges[number] = append(ranges[number], lineRanges...)
+		}
+	}
+
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	for number, lineRanges := range ranges {
+		ranges[number] = snippet.MergeRanges(lineRanges)
+	}
+	return ranges
+}
+
+// verifiedSnippets renders the matched lines of a document, with context
+// lines around them, as highlight-table snippets and lists the exact match
+// positions.
+func verifiedSnippets(lines []snippet.Line, ranges map[int][]snippet.Range, context int, format string) entity.Map {
+	var matched []int
+	for i, line := range lines {
+		if len(ranges[line.Number]) > 0 {
+			matched = append(matched, i)
+		}
+	}
+
+	var snippets []string
+	for _, window := range snippet.Windows(matched, context, len(lines)) {
+		if len(snippets) == verifyMaxSnippets {
+			break
+		}
+		snippets = append(snippets, snippet.RenderRows(lines[window[0]:window[1]+1], ranges))
+	}
+
+	content := snippetContent(snippets, format)
+	content["matches"] = snippet.Matches(ranges)
+	return content
+}
@@ -0,0 +1,181 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ahmadrosid/heline/core/entity"
+)
+
+// TestVerifiedSearchPaging tests verifying candidates over cursor pages
+func TestVerifiedSearchPaging(t *testing.T) {
+	var cursors []interface{}
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Params map[string]interface{} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		cursors = append(cursors, body.Params["cursorMark"])
+
+		// Only one candidate per page matches, then an exhausted cursor
+		w.Header().Set("Content-Type", "application/json")
+		switch body.Params["cursorMark"] {
+		case "*":
+			fmt.Fprintln(w, `{"response":{"numFound":4,"docs":[
+				{"id":"a","content":["<tr><td class=\"hl-num\" data-line=\"1\"></td><td>x := 12</td></tr>"]},
+				{"id":"b","content":["<tr><td class=\"hl-num\" data-line=\"1\"></td><td>no digits</td></tr>"]}
+			]},"nextCursorMark":"c1"}`)
+		case "c1":
+			fmt.Fprintln(w, `{"response":{"numFound":4,"docs":[
+				{"id":"c","content":["<tr><td class=\"hl-num\" data-line=\"1\"></td><td>none</td></tr>"]},
+				{"id":"d","content":["<tr><td class=\"hl-num\" data-line=\"1\"></td><td>y := 3</td></tr>"]}
+			]},"nextCursorMark":"c2"}`)
+		default:
+			fmt.Fprintln(w, `{"response":{"numFound":4,"docs":[]},"nextCursorMark":"c2"}`)
+		}
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+
+	testCases := []struct {
+		name            string
+		url             string
+		expectedIDs     []string
+		expectedTotal   int
+		expectedPartial bool
+		expectedCursors int
+	}{
+		{"Every candidate checked", "/api/search?q=%5Cd%2B&mode=regex", []string{"a", "d"}, 2, false, 3},
+		{"Stops once the page is full", "/api/search?q=%5Cd%2B&mode=regex&sort=path&per_page=1", []string{"a"}, 2, true, 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cursors = nil
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			rr := httptest.NewRecorder()
+			handleSearch(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			var result entity.CodeSearchResult
+			if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			var ids []string
+			for _, hit := range result.Response.Hits {
+				ids = append(ids, fmt.Sprint(hit.ID["raw"]))
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tc.expectedIDs) {
+				t.Errorf("Expected hits %v, got %v", tc.expectedIDs, ids)
+			}
+			if result.Response.Total != tc.expectedTotal {
+				t.Errorf("Expected total %d, got %d", tc.expectedTotal, result.Response.Total)
+			}
+			if result.Response.Partial != tc.expectedPartial {
+				t.Errorf("Expected partial=%v, got %v", tc.expectedPartial, result.Response.Partial)
+			}
+			if len(cursors) != tc.expectedCursors {
+				t.Errorf("Expected %d Solr requests, got %v", tc.expectedCursors, cursors)
+			}
+		})
+	}
+}
+
+// TestCaseSensitiveSearch tests that case=sensitive finds terms inside
+// identifiers, as the default search does
+func TestCaseSensitiveSearch(t *testing.T) {
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		// Solr is asked on the case-preserving n-grams
+		if body.Query != "content_cs:Context" {
+			t.Errorf("Unexpected query %q", body.Query)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"response":{"numFound":2,"docs":[
+			{"id":"a","content":["<tr><td class=\"hl-num\" data-line=\"1\"></td><td>ctx := NewContext()</td></tr>"]},
+			{"id":"b","content":["<tr><td class=\"hl-num\" data-line=\"1\"></td><td>ctx := newcontext()</td></tr>"]}
+		]}}`)
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search?q=Context&case=sensitive", nil)
+	rr := httptest.NewRecorder()
+	handleSearch(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var result entity.CodeSearchResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	hits := result.Response.Hits
+	if len(hits) != 1 || hits[0].ID["raw"] != "a" {
+		t.Fatalf("Expected only the NewContext hit, got %+v", hits)
+	}
+
+	matches, _ := json.Marshal(hits[0].Content["matches"])
+	if string(matches) != `[{"end":17,"line":1,"start":10}]` {
+		t.Errorf("Expected Context to be marked inside NewContext, got %s", matches)
+	}
+}
+
+// TestWholeWordExclusion tests that negated terms under word=whole only
+// exclude the whole word
+func TestWholeWordExclusion(t *testing.T) {
+	mockSolr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		// The exclusion is left to the verifier
+		if body.Query != "content:bar" {
+			t.Errorf("Unexpected query %q", body.Query)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"response":{"numFound":2,"docs":[
+			{"id":"a","content":["<tr><td class=\"hl-num\" data-line=\"1\"></td><td>bar := foobar</td></tr>"]},
+			{"id":"b","content":["<tr><td class=\"hl-num\" data-line=\"1\"></td><td>bar := foo()</td></tr>"]}
+		]}}`)
+	}))
+	defer mockSolr.Close()
+
+	os.Setenv("SOLR_BASE_URL", mockSolr.URL)
+	defer os.Unsetenv("SOLR_BASE_URL")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search?q=bar+-foo&word=whole", nil)
+	rr := httptest.NewRecorder()
+	handleSearch(rr, req)
+
+	var result entity.CodeSearchResult
+	if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	hits := result.Response.Hits
+	if len(hits) != 1 || hits[0].ID["raw"] != "a" {
+		t.Fatalf("Expected only the foobar hit, got %+v", hits)
+	}
+}
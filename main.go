@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -12,15 +13,18 @@ import (
 func main() {
 	// Set up Solr schema if needed
 	fmt.Println("🔄 Checking and setting up Solr schema...")
-	if err := solr.SetupSchema(); err != nil {
+	if err := solr.SetupSchema(context.Background()); err != nil {
 		log.Printf("⚠️ Warning: Failed to set up Solr schema: %v\n", err)
 		// Continue anyway, as the schema might already be set up or will be set up later
 	}
 
+	// Drop cached searches as indexing jobs complete
+	go ghttp.WatchIndexJobs(context.Background())
+
 	port := "8000"
 
 	fmt.Printf("🚀 Starting server on http://localhost:%s\n", port)
-	err := http.ListenAndServe(":"+port, ghttp.Handler())
+	err := http.ListenAndServe(":"+port, ghttp.Handler(nil))
 	if err != nil {
 		println("❌ Server already started!")
 		println(err.Error())